@@ -0,0 +1,123 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// BatchError is returned by [InsertMany], [UpsertMany] and [DeleteMany] when a batch fails.
+// the rows data[Start:End] are those of the failed batch. without [Atomic], the batches before it are written
+type BatchError struct {
+	// Index of the batch, starts from 0
+	Index int
+	Start int
+	End   int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch %d (rows %d to %d): %v", e.Index, e.Start, e.End, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// runBatches call exec for the batches of n rows of at most batchSize rows.
+// in atomic mode, the batches run in a transaction when there are more than one.
+// with parallelism, they run concurrently unless db is a *sql.Tx
+func runBatches(ctx context.Context, db DB, conf *config, n, batchSize int, exec func(ctx context.Context, db DB, start, end int) error) error {
+	run := func(db DB) error {
+		if _, ok := db.(*sql.Tx); !ok && conf.parallelism > 1 && n > batchSize {
+			return runBatchesParallel(ctx, db, conf.parallelism, n, batchSize, exec)
+		}
+		for index, start := 0, 0; start < n; index, start = index+1, start+batchSize {
+			end := min(start+batchSize, n)
+			if err := exec(ctx, db, start, end); err != nil {
+				return &BatchError{Index: index, Start: start, End: end, Err: err}
+			}
+		}
+		return nil
+	}
+
+	if conf.atomic && n > batchSize {
+		return inTx(ctx, conf, db, run)
+	}
+	return run(db)
+}
+
+// runBatchesParallel run at most parallelism batches at the same time.
+// the first failure cancels the batches running, and the remaining ones are not started
+func runBatchesParallel(ctx context.Context, db DB, parallelism, n, batchSize int, exec func(ctx context.Context, db DB, start, end int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, parallelism)
+	)
+	for index, start := 0, 0; start < n; index, start = index+1, start+batchSize {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		end := min(start+batchSize, n)
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := exec(ctx, db, start, end); err != nil {
+				once.Do(func() {
+					firstErr = &BatchError{Index: index, Start: start, End: end, Err: err}
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		// canceled by the caller
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// batchRows returns the number of rows of a batch among n rows of params parameters each.
+// it is at most the batch size, and shrunk so that the statement generated by query stays within
+// the max params of the dialect and the max statement bytes
+func batchRows(conf *config, n, params int, query func(rows int) string) (int, error) {
+	rows := max(min(conf.batchSize, n), 1)
+
+	maxParams := conf.maxParams
+	if maxParams <= 0 {
+		maxParams = conf.dialect.MaxParams()
+	}
+	if params > maxParams {
+		return 0, fmt.Errorf("%w: %d params per row exceed the max params %d", ErrStatementTooLarge, params, maxParams)
+	}
+	if params > 0 {
+		rows = min(rows, maxParams/params)
+	}
+
+	if conf.maxStatementBytes > 0 {
+		for size := len(query(rows)); size > conf.maxStatementBytes; size = len(query(rows)) {
+			if rows == 1 {
+				return 0, fmt.Errorf("%w: %d bytes per row exceed the max statement bytes %d", ErrStatementTooLarge, size, conf.maxStatementBytes)
+			}
+			// statements grow about linearly with the rows
+			rows = max(min(rows*conf.maxStatementBytes/size, rows-1), 1)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,117 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_InsertMany_BatchError(t *testing.T) {
+	db := initDb(t)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	_, err := db.Exec("CREATE UNIQUE INDEX userinfo_username ON userinfo(username)")
+	assert.Nil(t, err)
+
+	newUsers := func() []*UserInfo {
+		users := make([]*UserInfo, 5)
+		for i := range users {
+			users[i] = &UserInfo{Username: fmt.Sprintf("user%d", i)}
+		}
+		// conflicts with the first row
+		users[3].Username = "user0"
+		return users
+	}
+
+	err = InsertMany(ctx, db, "userinfo", newUsers(), WithBatchSize(2), Atomic(true))
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 1, batchErr.Index)
+	assert.Equal(t, 2, batchErr.Start)
+	assert.Equal(t, 4, batchErr.End)
+	assert.NotNil(t, errors.Unwrap(batchErr))
+	assert.Equal(t, 0, countUsers(t, db))
+
+	err = WithTx(ctx, db, func(tx DB) error {
+		assert.Nil(t, InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "outer"}))
+		err := InsertMany(ctx, tx, "userinfo", newUsers(), WithBatchSize(2), Atomic(true))
+		assert.True(t, errors.As(err, &batchErr))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, countUsers(t, db))
+
+	err = InsertMany(ctx, db, "userinfo", newUsers(), WithBatchSize(2))
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 1, batchErr.Index)
+	assert.Equal(t, 3, countUsers(t, db))
+}
+
+func Test_InsertMany_Parallelism(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "parallel.db")+"?_busy_timeout=5000")
+	assert.Nil(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	_, err = db.Exec(`
+	CREATE TABLE userinfo (
+	 	uid INTEGER PRIMARY KEY AUTOINCREMENT,
+	 	username VARCHAR(64) NULL UNIQUE,
+	 	department VARCHAR(64) NULL,
+	 	created DATE NULL,
+		version INTEGER NOT NULL DEFAULT 0
+	 )`)
+	assert.Nil(t, err)
+
+	var running, maxRunning atomic.Int32
+	track := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return next(ctx)
+	})
+
+	users := make([]*UserInfo, 10)
+	for i := range users {
+		users[i] = &UserInfo{Username: fmt.Sprintf("user%d", i)}
+	}
+	err = InsertMany(ctx, db, "userinfo", users, WithDialect(SQLite), WithBatchSize(2), WithParallelism(3), track)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, countUsers(t, db))
+	assert.Greater(t, maxRunning.Load(), int32(1))
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+	for _, user := range users {
+		u, err := GetOne[UserInfo](ctx, db, "select * from userinfo where uid = ?", user.Uid)
+		assert.Nil(t, err)
+		assert.Equal(t, user.Username, u.Username)
+	}
+
+	// sequential in a transaction
+	maxRunning.Store(0)
+	err = WithTx(ctx, db, func(tx DB) error {
+		return DeleteMany(ctx, tx, "userinfo", users, WithBatchSize(2), WithParallelism(3), track)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), maxRunning.Load())
+	assert.Equal(t, 0, countUsers(t, db))
+
+	users[5].Username = "user0"
+	err = InsertMany(ctx, db, "userinfo", users, WithBatchSize(2), WithParallelism(3))
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	// the first and the third batch conflict, whichever runs last fails
+	assert.Contains(t, []int{0, 2}, batchErr.Index)
+	assert.Equal(t, batchErr.Index*2, batchErr.Start)
+	assert.Equal(t, batchErr.Index*2+2, batchErr.End)
+}
@@ -0,0 +1,164 @@
+package orm
+
+import (
+	"context"
+	"iter"
+	"strings"
+)
+
+// SelectBuilder builds a SELECT statement whose rows are scanned into T
+//
+//	users, err := orm.Select[UserInfo]().
+//		From("userinfo").
+//		Where("department = ?", department).
+//		WhereIf(name != "", "username LIKE ?", name).
+//		OrderBy("uid DESC").
+//		Limit(20).
+//		All(ctx, db)
+//
+// conditions use "?" placeholders whatever the dialect, and slices are expanded. see [RewriteQuery].
+// a builder is not safe for concurrent use
+type SelectBuilder[T any] struct {
+	columns []string
+	table   string
+	wheres  []string
+	args    []any
+	orders  []string
+	limit   int
+	offset  int
+}
+
+// Select start building a query selecting columns. the columns of T are selected if none
+func Select[T any](columns ...string) *SelectBuilder[T] {
+	return &SelectBuilder[T]{
+		columns: columns,
+	}
+}
+
+// From set the table to select from, the table name of T by default. see [Tabler]
+func (b *SelectBuilder[T]) From(table string) *SelectBuilder[T] {
+	b.table = table
+	return b
+}
+
+// Where add a condition, conditions are joined with "AND"
+func (b *SelectBuilder[T]) Where(condition string, args ...any) *SelectBuilder[T] {
+	b.wheres = append(b.wheres, condition)
+	b.args = append(b.args, args...)
+	return b
+}
+
+// WhereIf add a condition only when ok is true, e.g. for optional filters
+func (b *SelectBuilder[T]) WhereIf(ok bool, condition string, args ...any) *SelectBuilder[T] {
+	if ok {
+		return b.Where(condition, args...)
+	}
+	return b
+}
+
+// OrderBy add order expressions, e.g. "uid DESC"
+func (b *SelectBuilder[T]) OrderBy(orders ...string) *SelectBuilder[T] {
+	b.orders = append(b.orders, orders...)
+	return b
+}
+
+// Limit the number of rows, n <= 0 means no limit
+func (b *SelectBuilder[T]) Limit(n int) *SelectBuilder[T] {
+	b.limit = n
+	return b
+}
+
+// Offset skip the first n rows, only used with [SelectBuilder.Limit]
+func (b *SelectBuilder[T]) Offset(n int) *SelectBuilder[T] {
+	b.offset = n
+	return b
+}
+
+// Build returns the final query and args, in the syntax of the dialect of opts.
+// it fails when they can't be rewritten, see [RewriteQuery]
+func (b *SelectBuilder[T]) Build(opts ...func(*config)) (query string, args []any, err error) {
+	conf := *defaultConfig.Load()
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return rewriteQuery(&conf, b.sql(&conf, b.limit), b.args)
+}
+
+// One execute the query and get the first row, nil if none. see [GetOne]
+func (b *SelectBuilder[T]) One(ctx context.Context, db DB, opts ...func(*config)) (*T, error) {
+	conf, _ := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	limit := b.limit
+	if limit <= 0 {
+		limit = 1
+	}
+	return GetOne[T](ctx, db, b.sql(&conf, limit), b.queryArgs(opts)...)
+}
+
+// All execute the query and get all rows. see [GetMany]
+func (b *SelectBuilder[T]) All(ctx context.Context, db DB, opts ...func(*config)) ([]*T, error) {
+	conf, _ := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return GetMany[T](ctx, db, b.sql(&conf, b.limit), b.queryArgs(opts)...)
+}
+
+// Iter execute the query and iterate the rows lazily. see [GetIter]
+func (b *SelectBuilder[T]) Iter(ctx context.Context, db DB, opts ...func(*config)) iter.Seq2[*T, error] {
+	conf, _ := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return GetIter[T](ctx, db, b.sql(&conf, b.limit), b.queryArgs(opts)...)
+}
+
+// queryArgs returns the args for GetOne/GetMany, the query is always rewritten as it uses "?" placeholders
+func (b *SelectBuilder[T]) queryArgs(opts []func(*config)) []any {
+	args := make([]any, 0, len(b.args)+len(opts)+1)
+	args = append(args, b.args...)
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return append(args, EnableRewriteQuery(true))
+}
+
+// sql returns the query with "?" placeholders
+func (b *SelectBuilder[T]) sql(conf *config, limit int) string {
+	table := b.table
+	if table == "" {
+		table = tableNameOf[T]()
+	}
+
+	w := newSQLWriter(conf.dialect)
+	if len(b.columns) == 0 {
+		writeSelectSQL(w, table, modelColumns[T](conf))
+	} else {
+		w.WriteString("SELECT ")
+		w.WriteString(strings.Join(b.columns, separator))
+		w.WriteString(" FROM ")
+		w.WriteString(table)
+	}
+	for i, where := range b.wheres {
+		if i == 0 {
+			w.WriteString(" WHERE ")
+		} else {
+			w.WriteString(" AND ")
+		}
+		if len(b.wheres) > 1 {
+			w.WriteString("(" + where + ")")
+		} else {
+			w.WriteString(where)
+		}
+	}
+	if len(b.orders) > 0 {
+		w.WriteString(" ORDER BY ")
+		w.WriteString(strings.Join(b.orders, separator))
+	}
+	if limit > 0 {
+		w.WriteString(conf.dialect.Limit(limit, b.offset))
+	}
+	return w.String()
+}
@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelectBuilder_Build(t *testing.T) {
+	name := ""
+	query, args, err := Select[UserInfo]("uid", "username").
+		From("userinfo").
+		Where("department = ?", "dev").
+		WhereIf(name != "", "username LIKE ?", name).
+		Where("uid IN ? OR uid = ?", []int{1, 2}, 3).
+		OrderBy("uid DESC").
+		Limit(20).
+		Offset(40).
+		Build(WithDialect(Postgres))
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT uid,username FROM userinfo WHERE (department = $1) AND (uid IN ($2,$3) OR uid = $4) ORDER BY uid DESC LIMIT 20 OFFSET 40", query)
+	assert.Equal(t, []any{"dev", 1, 2, 3}, args)
+
+	query, args, err = Select[UserInfo]().Build()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT `uid`,`username`,`department`,`created`,`version` FROM userinfo", query)
+	assert.Nil(t, args)
+
+	_, _, err = Select[UserInfo]().Where("uid = ?").Build()
+	assert.ErrorIs(t, err, ErrPlaceholderMismatch)
+	_, _, err = Select[UserInfo]().Where("uid IN ?", []int{}).Build(WithEmptySliceMode(EmptySliceError))
+	assert.ErrorIs(t, err, ErrEmptyInClause)
+}
+
+func TestSelectBuilder_Query(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: fmt.Sprintf("user%d", i), Department: "dev"})
+		assert.Nil(t, err)
+	}
+
+	users, err := Select[UserInfo]().From("userinfo").
+		Where("department = ?", "dev").
+		WhereIf(true, "uid IN ?", []int{2, 3, 4}).
+		OrderBy("uid DESC").
+		Limit(2).
+		All(ctx, db)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, int64(4), users[0].Uid)
+	assert.Equal(t, int64(3), users[1].Uid)
+
+	user, err := Select[UserInfo]().From("userinfo").OrderBy("uid DESC").One(ctx, db, EnableRewriteQuery(false))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), user.Uid)
+
+	count := 0
+	for _, err := range Select[UserInfo]().Iter(ctx, db) {
+		assert.Nil(t, err)
+		count++
+	}
+	assert.Equal(t, 5, count)
+}
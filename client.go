@@ -0,0 +1,94 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+)
+
+var (
+	_ DB = (*Client)(nil)
+)
+
+// Client is a DB with its own config, so that several differently configured stacks can live in one binary.
+// the config is the default config when the client is created, modified by opts, and never changes after.
+//
+//	client := orm.New(db, orm.WithDialect(orm.Postgres), orm.WithTagName("db"))
+//	users, err := orm.GetMany[UserInfo](ctx, client, "select * from userinfo where uid in ?", uids)
+//	err = client.InsertOne(ctx, "userinfo", &user)
+//
+// every function of the package taking a DB runs with the config of the client when given one,
+// options passed to the function still apply on top of it. generic functions like [GetOne] and
+// [GetMany] can't be methods, pass the client to them instead. given a plain DB, they run it as a
+// client of the default config.
+//
+// each client has its own cache of prepared statements, sized by [WithStmtCacheSize], which is
+// closed by [Client.Close]. transactions of a client use its cache. scan plans are shared by all
+// clients, they only depend on the model, the tag name and the result columns
+type Client struct {
+	db   DB
+	conf config
+}
+
+// New returns a client running statements on db with the default config modified by opts
+func New(db DB, opts ...func(*config)) *Client {
+	conf, db := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	conf.interceptors = slices.Clip(conf.interceptors)
+	conf.stmts = newStmtCache(conf.stmtCacheSize)
+	return &Client{db: db, conf: conf}
+}
+
+// Close closes the statements cached by the client, the underlying DB is left open.
+// statements in use are closed when they are done, and the client doesn't cache statements after
+func (c *Client) Close() error {
+	c.conf.stmts.resize(0)
+	return nil
+}
+
+// DB returns the underlying DB of the client
+func (c *Client) DB() DB {
+	return c.db
+}
+
+// QueryContext run query on the underlying DB through the interceptors of the client, as is
+func (c *Client) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return queryContext(ctx, &c.conf, c.db, query, args...)
+}
+
+// ExecContext run query on the underlying DB through the interceptors of the client, as is
+func (c *Client) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execContext(ctx, &c.conf, c.db, query, args...)
+}
+
+// InsertOne is [InsertOne] with the config of the client
+func (c *Client) InsertOne(ctx context.Context, tableName string, data any, opts ...func(*config)) error {
+	return InsertOne(ctx, c, tableName, data, opts...)
+}
+
+// UpsertOne is [UpsertOne] with the config of the client
+func (c *Client) UpsertOne(ctx context.Context, tableName string, data any, opts ...func(*config)) error {
+	return UpsertOne(ctx, c, tableName, data, opts...)
+}
+
+// UpdateOne is [UpdateOne] with the config of the client
+func (c *Client) UpdateOne(ctx context.Context, tableName string, data any, opts ...func(*config)) error {
+	return UpdateOne(ctx, c, tableName, data, opts...)
+}
+
+// DeleteOne is [DeleteOne] with the config of the client
+func (c *Client) DeleteOne(ctx context.Context, tableName string, data any, opts ...func(*config)) error {
+	return DeleteOne(ctx, c, tableName, data, opts...)
+}
+
+// GetCount is [GetCount] with the config of the client
+func (c *Client) GetCount(ctx context.Context, query string, args ...any) (int64, error) {
+	return GetCount(ctx, c, query, args...)
+}
+
+// WithTx is [WithTx] with the config of the client, tx is a client of the same config
+func (c *Client) WithTx(ctx context.Context, fn func(tx DB) error, opts ...func(*config)) error {
+	return WithTx(ctx, c, fn, opts...)
+}
@@ -0,0 +1,105 @@
+package orm
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func Test_Client(t *testing.T) {
+	db := initDb(t)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	var queries []string
+	record := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		queries = append(queries, info.Query)
+		return next(ctx)
+	})
+	sqlite := New(db, WithDialect(SQLite), record)
+	custom := New(db, WithTagName("foobar"))
+	assert.Equal(t, db, sqlite.DB())
+
+	assert.Nil(t, sqlite.InsertOne(ctx, "userinfo", &UserInfo{Username: "astaxie"}))
+	assert.Equal(t, `INSERT INTO userinfo("username","department","created","version") VALUES (?,?,?,?)`, queries[0])
+
+	u, err := GetOne[CustomTagUserInfo](ctx, custom, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie", u.Username)
+	// options still apply on top of the config of the client
+	u2, err := GetOne[UserInfo](ctx, custom, "select * from userinfo where uid = ?", 1, WithTagName("orm"))
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie", u2.Username)
+
+	queries = nil
+	err = sqlite.WithTx(ctx, func(tx DB) error {
+		_, ok := tx.(*Client)
+		assert.True(t, ok)
+		return InsertMany(ctx, tx, "userinfo", []*UserInfo{{Username: "a"}, {Username: "b"}})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(queries))
+	count, err := sqlite.GetCount(ctx, "select count(*) from userinfo")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, 2, len(queries))
+
+	// a client of a client has the config of both
+	nested := New(sqlite, WithBatchSize(1))
+	assert.Equal(t, SQLite, nested.conf.dialect)
+	assert.Equal(t, 1, nested.conf.batchSize)
+	assert.Equal(t, db, nested.DB())
+}
+
+func Test_Client_StmtCache(t *testing.T) {
+	db := initDb(t)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	before := defaultConfig.Load().stmts.len()
+
+	client := New(db, WithDialect(SQLite), EnableStmtCache(true), WithStmtCacheSize(1))
+	assert.Equal(t, 1, client.conf.stmts.size)
+	for _, name := range []string{"a", "b"} {
+		assert.Nil(t, client.InsertOne(ctx, "userinfo", &UserInfo{Username: name}))
+	}
+	assert.Nil(t, client.UpdateOne(ctx, "userinfo", &UserInfo{Uid: 1, Username: "aa"}))
+	// the statement prepared on the db is reused by transactions
+	err := client.WithTx(ctx, func(tx DB) error {
+		return UpdateOne(ctx, tx, "userinfo", &UserInfo{Uid: 2, Username: "bb"})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, client.conf.stmts.len())
+	// the cache of the package functions is untouched
+	assert.Equal(t, before, defaultConfig.Load().stmts.len())
+	assert.Equal(t, 2, countUsers(t, db))
+
+	assert.Nil(t, client.Close())
+	assert.Equal(t, 0, client.conf.stmts.len())
+	assert.Nil(t, client.InsertOne(ctx, "userinfo", &UserInfo{Username: "c"}))
+	assert.Equal(t, 0, client.conf.stmts.len())
+}
+
+func Test_DefaultConfig_Race(t *testing.T) {
+	db := initDb(t)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	defer SetBatchSize(200)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			SetBatchSize(100 + i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			_, err := GetMany[UserInfo](ctx, db, "select * from userinfo")
+			assert.Nil(t, err)
+		}
+	}()
+	wg.Wait()
+}
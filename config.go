@@ -1,9 +1,35 @@
 package orm
 
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
 const (
 	TagPrimaryKey    = "primary"
 	TagAutoIncrement = "autoincrement"
 	TagVersion       = "version"
+	// TagNullZero scans NULL as zero value, and writes zero value as NULL
+	TagNullZero = "nullzero"
+)
+
+// EmptySliceMode is how an empty slice arg is rewritten. see [RewriteQuery]
+type EmptySliceMode int
+
+const (
+	// EmptySliceNull rewrites the placeholder to "(NULL)", so "IN ?" and "NOT IN ?" both match no row
+	EmptySliceNull EmptySliceMode = iota
+	// EmptySliceError fails the query with [ErrEmptyInClause]
+	EmptySliceError
+	// EmptySliceSkip returns no row without executing the query, whatever the predicate is.
+	// queries returning a single value, e.g. [GetCount], return the zero value
+	EmptySliceSkip
+	// EmptySliceRewrite rewrites "x IN ?" to "1=0" and "x NOT IN ?" to "1=1".
+	// the query fails with [ErrEmptyInClause] when the placeholder doesn't follow "IN" or "NOT IN"
+	EmptySliceRewrite
 )
 
 type config struct {
@@ -11,31 +37,201 @@ type config struct {
 	enableOptimisticLock bool
 	rewriteQuery         bool
 	batchSize            int
+	dialect              Dialect
+	conflictColumns      []string
+	upsertColumns        []string
+	txOptions            *sql.TxOptions
+	txRetries            int
+	interceptors         []Interceptor
+	columns              []string
+	omit                 []string
+	nullAsZero           bool
+	strict               bool
+	onColumnMismatch     func(ctx context.Context, mismatch *ColumnMismatchError)
+	stmtCache            bool
+	stmtCacheSize        int
+	stmts                *stmtCache
+	maxParams            int
+	maxStatementBytes    int
+	atomic               bool
+	parallelism          int
+	emptySlice           EmptySliceMode
+	rowValues            bool
+	requireInsertIDs     bool
 }
 
 var (
-	defaultConfig = config{
+	// defaultConfig is the config of the package functions, and the base of the config of clients.
+	// it is replaced as a whole by the setters, so that it can be read without lock
+	defaultConfig   atomic.Pointer[config]
+	defaultConfigMu sync.Mutex
+)
+
+func init() {
+	defaultConfig.Store(&config{
 		tagName:              "orm",
 		enableOptimisticLock: false,
 		rewriteQuery:         true,
 		batchSize:            200,
+		dialect:              MySQL,
+		rowValues:            true,
+		stmtCacheSize:        128,
+		stmts:                newStmtCache(128),
+	})
+}
+
+// updateDefaultConfig replace the default config with a copy modified by update
+func updateDefaultConfig(update func(c *config)) {
+	defaultConfigMu.Lock()
+	defer defaultConfigMu.Unlock()
+	conf := *defaultConfig.Load()
+	update(&conf)
+	defaultConfig.Store(&conf)
+}
+
+// configOf returns the config to run statements on db with, and the DB to run them on.
+// it is the config of db when db is a [*Client]. otherwise db is run as a client of the default config,
+// sharing the statement cache of the package functions
+func configOf(db DB) (config, DB) {
+	if c, ok := db.(*Client); ok {
+		return c.conf, c.db
 	}
-)
+	return *defaultConfig.Load(), db
+}
 
 func SetTagName(tag string) {
-	defaultConfig.tagName = tag
+	updateDefaultConfig(func(c *config) {
+		c.tagName = tag
+	})
 }
 
 func SetEnableOptimisticLock(enabled bool) {
-	defaultConfig.enableOptimisticLock = enabled
+	updateDefaultConfig(func(c *config) {
+		c.enableOptimisticLock = enabled
+	})
 }
 
 func SetRewriteQuery(enabled bool) {
-	defaultConfig.rewriteQuery = enabled
+	updateDefaultConfig(func(c *config) {
+		c.rewriteQuery = enabled
+	})
 }
 
 func SetBatchSize(batchSize int) {
-	defaultConfig.batchSize = batchSize
+	updateDefaultConfig(func(c *config) {
+		c.batchSize = batchSize
+	})
+}
+
+// SetDialect set the default dialect used to generate SQL. default is [MySQL]
+func SetDialect(d Dialect) {
+	updateDefaultConfig(func(c *config) {
+		c.dialect = d
+	})
+}
+
+// SetInterceptors set the interceptors every statement goes through by default
+func SetInterceptors(interceptors ...Interceptor) {
+	updateDefaultConfig(func(c *config) {
+		c.interceptors = interceptors
+	})
+}
+
+// SetNullAsZero set whether NULL is scanned as zero value into fields which can't hold NULL.
+// unlike the "nullzero" tag attribute, zero values are still written as is
+func SetNullAsZero(enabled bool) {
+	updateDefaultConfig(func(c *config) {
+		c.nullAsZero = enabled
+	})
+}
+
+// SetStrict set whether queries fail with [*ColumnMismatchError] when the result columns don't match
+// the fields of the model: a result column has no field, or a field has no result column
+func SetStrict(enabled bool) {
+	updateDefaultConfig(func(c *config) {
+		c.strict = enabled
+	})
+}
+
+// SetOnColumnMismatch set the function reporting mismatches between the result columns and the fields
+// of the model, when not in strict mode. it is called once per query, e.g. to log schema drifts
+func SetOnColumnMismatch(fn func(ctx context.Context, mismatch *ColumnMismatchError)) {
+	updateDefaultConfig(func(c *config) {
+		c.onColumnMismatch = fn
+	})
+}
+
+// SetRequireInsertIDs set whether [InsertMany] fails with [ErrInsertIDUnavailable] when the generated ids
+// can't be set back, default is false: the rows are written and their ids are left unset.
+// multi-row batches are checked before anything is written, e.g. for the auto_increment_increment of MySQL
+func SetRequireInsertIDs(required bool) {
+	updateDefaultConfig(func(c *config) {
+		c.requireInsertIDs = required
+	})
+}
+
+// SetEnableStmtCache set whether generated INSERT, UPDATE and DELETE statements are prepared once
+// and reused, per *sql.DB. statements in transactions begun by [WithTx] reuse them too.
+// statements are kept until evicted, see [PurgeStmtCache] for a *sql.DB being closed.
+// keep it disabled with transaction pooling, e.g. PgBouncer, where prepared statements aren't supported
+func SetEnableStmtCache(enabled bool) {
+	updateDefaultConfig(func(c *config) {
+		c.stmtCache = enabled
+	})
+}
+
+// SetStmtCacheSize set how many prepared statements are cached by the package functions, the least recently used
+// are closed. default is 128. it is also the size of the caches of the clients created after, see [WithStmtCacheSize]
+func SetStmtCacheSize(size int) {
+	updateDefaultConfig(func(c *config) {
+		c.stmtCacheSize = size
+		c.stmts.resize(size)
+	})
+}
+
+// PurgeStmtCache close the statements the package functions prepared on db, and forget what was learnt
+// about db, e.g. the step of its generated ids. call it when closing a *sql.DB, as it can't be garbage
+// collected until then. the caches of clients are closed by [Client.Close]
+func PurgeStmtCache(db *sql.DB) {
+	defaultConfig.Load().stmts.purge(db)
+	increments.Range(func(key, _ any) bool {
+		if key.(incrementKey).db == db {
+			increments.Delete(key)
+		}
+		return true
+	})
+}
+
+// SetMaxParams set the maximum number of bind parameters of a statement, batches of [InsertMany],
+// [UpsertMany] and [DeleteMany] are shrunk to stay within it. default is the MaxParams of the dialect
+func SetMaxParams(n int) {
+	updateDefaultConfig(func(c *config) {
+		c.maxParams = n
+	})
+}
+
+// SetMaxStatementBytes set the maximum length of the SQL text of a statement, e.g. max_allowed_packet of MySQL.
+// batches are shrunk to stay within it like [SetMaxParams]. the args are not counted. default is no limit
+func SetMaxStatementBytes(n int) {
+	updateDefaultConfig(func(c *config) {
+		c.maxStatementBytes = n
+	})
+}
+
+// SetEmptySliceMode set how empty slice args are rewritten, default is [EmptySliceNull]
+func SetEmptySliceMode(mode EmptySliceMode) {
+	updateDefaultConfig(func(c *config) {
+		c.emptySlice = mode
+	})
+}
+
+// SetRowValues set whether the database supports row values, e.g. "(a, b) IN ((?,?),(?,?))", default is true.
+// otherwise IN predicates of tuples, see [Tuples], and the composite keys of [DeleteMany] are written as
+// comparisons joined with "OR", e.g. for SQLite before 3.15.0
+func SetRowValues(enabled bool) {
+	updateDefaultConfig(func(c *config) {
+		c.rowValues = enabled
+	})
 }
 
 func WithTagName(tag string) func(c *config) {
@@ -61,3 +257,151 @@ func WithBatchSize(batchSize int) func(c *config) {
 		c.batchSize = batchSize
 	}
 }
+
+func WithDialect(d Dialect) func(c *config) {
+	return func(c *config) {
+		c.dialect = d
+	}
+}
+
+// ConflictColumns set the conflict target of upserts. default is the "primary" tagged columns
+func ConflictColumns(columns ...string) func(c *config) {
+	return func(c *config) {
+		c.conflictColumns = columns
+	}
+}
+
+// UpdateColumns set the columns overwritten by upserts on conflict. default is all inserted columns
+func UpdateColumns(columns ...string) func(c *config) {
+	return func(c *config) {
+		c.upsertColumns = columns
+	}
+}
+
+// WithTxOptions set the options to begin transactions with in [WithTx]
+func WithTxOptions(opts *sql.TxOptions) func(c *config) {
+	return func(c *config) {
+		c.txOptions = opts
+	}
+}
+
+// WithTxRetries set how many times [WithTx] retries a transaction failed because of
+// [ErrConcurrencyUpdate], serialization failures or deadlocks. default is 0
+func WithTxRetries(retries int) func(c *config) {
+	return func(c *config) {
+		c.txRetries = retries
+	}
+}
+
+// WithInterceptors add interceptors after the default ones, see [SetInterceptors]
+func WithInterceptors(interceptors ...Interceptor) func(c *config) {
+	return func(c *config) {
+		c.interceptors = append(slices.Clip(c.interceptors), interceptors...)
+	}
+}
+
+// Columns restrict [UpdateOne] to update only the given columns.
+// primary key and version columns are always used
+func Columns(columns ...string) func(c *config) {
+	return func(c *config) {
+		c.columns = columns
+	}
+}
+
+// Omit prevent [UpdateOne] from updating the given columns
+func Omit(columns ...string) func(c *config) {
+	return func(c *config) {
+		c.omit = columns
+	}
+}
+
+// NullAsZero is the per call version of [SetNullAsZero]
+func NullAsZero(enabled bool) func(c *config) {
+	return func(c *config) {
+		c.nullAsZero = enabled
+	}
+}
+
+// Strict is the per call version of [SetStrict]
+func Strict(enabled bool) func(c *config) {
+	return func(c *config) {
+		c.strict = enabled
+	}
+}
+
+// OnColumnMismatch is the per call version of [SetOnColumnMismatch]
+func OnColumnMismatch(fn func(ctx context.Context, mismatch *ColumnMismatchError)) func(c *config) {
+	return func(c *config) {
+		c.onColumnMismatch = fn
+	}
+}
+
+// EnableStmtCache set whether generated statements are prepared and reused. see [SetEnableStmtCache]
+func EnableStmtCache(enabled bool) func(c *config) {
+	return func(c *config) {
+		c.stmtCache = enabled
+	}
+}
+
+// WithStmtCacheSize set the size of the statement cache of a client, it is only used by [New].
+// see [SetStmtCacheSize]
+func WithStmtCacheSize(size int) func(c *config) {
+	return func(c *config) {
+		c.stmtCacheSize = size
+	}
+}
+
+// WithMaxParams is the per call version of [SetMaxParams]
+func WithMaxParams(n int) func(c *config) {
+	return func(c *config) {
+		c.maxParams = n
+	}
+}
+
+// WithMaxStatementBytes is the per call version of [SetMaxStatementBytes]
+func WithMaxStatementBytes(n int) func(c *config) {
+	return func(c *config) {
+		c.maxStatementBytes = n
+	}
+}
+
+// Atomic set whether [InsertMany], [UpsertMany] and [DeleteMany] write all the batches or none.
+// the batches run in a transaction begun on db, or in a savepoint when db is a *sql.Tx.
+// [ErrTxNotSupported] is returned when db can't begin transactions.
+// on failure, the ids already set back to the rows are kept
+func Atomic(enabled bool) func(c *config) {
+	return func(c *config) {
+		c.atomic = enabled
+	}
+}
+
+// WithParallelism set how many batches of [InsertMany], [UpsertMany] and [DeleteMany] run at the same time,
+// each on a connection of the pool. the first failed batch cancels the others.
+// batches run one after another when db is a *sql.Tx, including in [Atomic] mode.
+// interceptors must be safe for concurrent use
+func WithParallelism(n int) func(c *config) {
+	return func(c *config) {
+		c.parallelism = n
+	}
+}
+
+// WithEmptySliceMode is the per call version of [SetEmptySliceMode]
+func WithEmptySliceMode(mode EmptySliceMode) func(c *config) {
+	return func(c *config) {
+		c.emptySlice = mode
+	}
+}
+
+// WithRowValues is the per call version of [SetRowValues]
+func WithRowValues(enabled bool) func(c *config) {
+	return func(c *config) {
+		c.rowValues = enabled
+	}
+}
+
+// RequireInsertIDs is the per call version of [SetRequireInsertIDs]
+func RequireInsertIDs(required bool) func(c *config) {
+	return func(c *config) {
+		c.requireInsertIDs = required
+	}
+}
@@ -0,0 +1,345 @@
+package orm
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// Dialect describes the syntax differences between databases.
+// every SQL generator in this package goes through the dialect of the config
+type Dialect interface {
+	// Name returns the name of the database, e.g. "mysql"
+	Name() string
+	// Placeholder returns the bind parameter marker of the n-th argument, n starts from 1
+	Placeholder(n int) string
+	// Quote quotes an identifier such as a column name
+	Quote(identifier string) string
+	// BackslashEscapes reports whether a backslash escapes the next character of string literals,
+	// so that the literals are skipped properly when queries are rewritten
+	BackslashEscapes() bool
+	// HashComments reports whether "#" starts a comment running to the end of the line
+	HashComments() bool
+	// NamedPrefixes returns the characters starting the named parameters bound by [Named], e.g. ":@".
+	// a character the database gives another meaning, e.g. "@" of MySQL user variables, must be left out
+	NamedPrefixes() string
+	// Limit returns the clause restricting the rows of a query. offset <= 0 means no offset
+	Limit(limit, offset int) string
+	// Returning returns the clause making an INSERT/UPDATE statement return the given columns.
+	// empty string means the database does not support it
+	Returning(columns []string) string
+	// OnConflict returns the clause turning an INSERT statement into an upsert.
+	// conflict is the conflict target, update are the columns overwritten by the inserted row.
+	// when version is not empty, the existing row is only updated when its version is older than the inserted one
+	OnConflict(tableName string, conflict, update []string, version string) string
+	// FirstInsertID derives the id generated for the first row of a multi-row insert statement from
+	// [sql.Result]. ok is false when the database does not generate consecutive ids for the rows
+	FirstInsertID(lastInsertID, rowsAffected int64) (id int64, ok bool)
+	// IncrementQuery returns the query selecting the step between generated ids, which must be 1 for
+	// [Dialect.FirstInsertID] to hold. empty string means the step is always 1
+	IncrementQuery() string
+	// IsSerializationFailure reports whether err is a serialization failure or deadlock,
+	// after which the transaction can be retried
+	IsSerializationFailure(err error) bool
+	// MaxParams returns the maximum number of bind parameters of a statement
+	MaxParams() int
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Placeholder(int) string {
+	return placeholder
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, "`")
+}
+
+// BackslashEscapes is true unless the NO_BACKSLASH_ESCAPES sql mode is enabled
+func (mysqlDialect) BackslashEscapes() bool {
+	return true
+}
+
+func (mysqlDialect) HashComments() bool {
+	return true
+}
+
+// NamedPrefixes leaves out "@", which starts user variables, e.g. "@rn := @rn + 1"
+func (mysqlDialect) NamedPrefixes() string {
+	return ":"
+}
+
+func (mysqlDialect) Limit(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (mysqlDialect) Returning([]string) string {
+	return ""
+}
+
+// OnConflict ignores the conflict target, mysql detects conflicts on every unique key.
+// the version column is assigned last, as mysql evaluates assignments from left to right
+func (d mysqlDialect) OnConflict(_ string, conflict, update []string, version string) string {
+	sb := strings.Builder{}
+	sb.WriteString(" ON DUPLICATE KEY UPDATE ")
+	if len(update) == 0 && version == "" {
+		// no-op assignment to ignore the conflict
+		sb.WriteString(d.Quote(conflict[0]))
+		sb.WriteString(equals)
+		sb.WriteString(d.Quote(conflict[0]))
+		return sb.String()
+	}
+	for i, col := range withVersion(update, version) {
+		if i > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(d.Quote(col))
+		sb.WriteString(equals)
+		if version == "" {
+			sb.WriteString("VALUES(" + d.Quote(col) + ")")
+		} else {
+			sb.WriteString("IF(" + d.Quote(version) + "<VALUES(" + d.Quote(version) + "),VALUES(" + d.Quote(col) + ")," + d.Quote(col) + ")")
+		}
+	}
+	return sb.String()
+}
+
+// FirstInsertID relies on mysql returning the id of the first row as LastInsertId, and generating
+// consecutive ids for the rows of a simple insert. the latter needs "auto_increment_increment" to be 1,
+// which is checked with [Dialect.IncrementQuery]
+func (mysqlDialect) FirstInsertID(lastInsertID, _ int64) (int64, bool) {
+	return lastInsertID, true
+}
+
+// IncrementQuery selects "auto_increment_increment", which is often not 1 in multi-primary setups, e.g. Galera
+func (mysqlDialect) IncrementQuery() string {
+	return "SELECT @@auto_increment_increment"
+}
+
+// IsSerializationFailure matches deadlocks (1213) and lock wait timeouts (1205).
+// go-sql-driver/mysql formats them as "Error 1213 (40001): ..."
+func (mysqlDialect) IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return sqlState(err) == "40001" || strings.Contains(msg, "Error 1213") || strings.Contains(msg, "Error 1205")
+}
+
+func (mysqlDialect) MaxParams() int {
+	return 65535
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, `"`)
+}
+
+// BackslashEscapes is false, as standard_conforming_strings is on by default.
+// backslashes still escape in escape strings, e.g. E'it\'s'
+func (postgresDialect) BackslashEscapes() bool {
+	return false
+}
+
+// HashComments is false, "#" is the bitwise XOR operator
+func (postgresDialect) HashComments() bool {
+	return false
+}
+
+// NamedPrefixes leaves out "@", which is the absolute value operator, e.g. "@x"
+func (postgresDialect) NamedPrefixes() string {
+	return ":"
+}
+
+func (postgresDialect) Limit(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (d postgresDialect) Returning(columns []string) string {
+	return returning(d, columns)
+}
+
+func (d postgresDialect) OnConflict(tableName string, conflict, update []string, version string) string {
+	return onConflictExcluded(d, tableName, conflict, update, version)
+}
+
+// FirstInsertID is not supported, postgres has no LastInsertId. "RETURNING" is used instead
+func (postgresDialect) FirstInsertID(int64, int64) (int64, bool) {
+	return 0, false
+}
+
+func (postgresDialect) IncrementQuery() string {
+	return ""
+}
+
+func (postgresDialect) IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch sqlState(err) {
+	case "40001", "40P01":
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "could not serialize access") || strings.Contains(msg, "deadlock detected")
+}
+
+func (postgresDialect) MaxParams() int {
+	return 65535
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Placeholder(int) string {
+	return placeholder
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, `"`)
+}
+
+func (sqliteDialect) BackslashEscapes() bool {
+	return false
+}
+
+func (sqliteDialect) HashComments() bool {
+	return false
+}
+
+// NamedPrefixes are ":" and "@", which sqlite takes as named bind parameters as well
+func (sqliteDialect) NamedPrefixes() string {
+	return ":@"
+}
+
+func (sqliteDialect) Limit(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+// Returning is not used for sqlite. the order of rows returned by "RETURNING" is arbitrary in sqlite,
+// LastInsertId is reliable instead
+func (sqliteDialect) Returning([]string) string {
+	return ""
+}
+
+func (d sqliteDialect) OnConflict(tableName string, conflict, update []string, version string) string {
+	return onConflictExcluded(d, tableName, conflict, update, version)
+}
+
+// FirstInsertID relies on sqlite returning the rowid of the last row as LastInsertId.
+// writes are serialized by sqlite, so the rows of one statement get consecutive ids
+func (sqliteDialect) FirstInsertID(lastInsertID, rowsAffected int64) (int64, bool) {
+	return lastInsertID - rowsAffected + 1, true
+}
+
+func (sqliteDialect) IncrementQuery() string {
+	return ""
+}
+
+// IsSerializationFailure matches SQLITE_BUSY and SQLITE_LOCKED
+func (sqliteDialect) IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
+
+// MaxParams is SQLITE_MAX_VARIABLE_NUMBER since SQLite 3.32.0, it was 999 before.
+// use [WithMaxParams] for older versions
+func (sqliteDialect) MaxParams() int {
+	return 32766
+}
+
+// quoteIdentifier quotes identifier with q, q inside identifier is escaped by doubling it
+func quoteIdentifier(identifier, q string) string {
+	return q + strings.ReplaceAll(identifier, q, q+q) + q
+}
+
+func limitOffset(limit, offset int) string {
+	s := " LIMIT " + strconv.Itoa(limit)
+	if offset > 0 {
+		s += " OFFSET " + strconv.Itoa(offset)
+	}
+	return s
+}
+
+func returning(d Dialect, columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+	sb := strings.Builder{}
+	sb.WriteString(" RETURNING ")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(d.Quote(col))
+	}
+	return sb.String()
+}
+
+// onConflictExcluded generate the "ON CONFLICT ... DO UPDATE" clause shared by postgres and sqlite,
+// the inserted row is referred as "excluded"
+func onConflictExcluded(d Dialect, tableName string, conflict, update []string, version string) string {
+	sb := strings.Builder{}
+	sb.WriteString(" ON CONFLICT (")
+	for i, col := range conflict {
+		if i > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(d.Quote(col))
+	}
+	sb.WriteString(")")
+	if len(update) == 0 && version == "" {
+		sb.WriteString(" DO NOTHING")
+		return sb.String()
+	}
+	sb.WriteString(" DO UPDATE SET ")
+	for i, col := range withVersion(update, version) {
+		if i > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(d.Quote(col))
+		sb.WriteString(equals)
+		sb.WriteString("excluded.")
+		sb.WriteString(d.Quote(col))
+	}
+	if version != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(tableName + "." + d.Quote(version))
+		sb.WriteString("<excluded.")
+		sb.WriteString(d.Quote(version))
+	}
+	return sb.String()
+}
+
+// withVersion appends the version column, if any, to the updated columns
+func withVersion(update []string, version string) []string {
+	if version == "" {
+		return update
+	}
+	return append(slices.Clip(update), version)
+}
@@ -0,0 +1,73 @@
+package orm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDialect_GenerateSQL(t *testing.T) {
+	columns := []string{"username", "department"}
+
+	assert.Equal(t, "INSERT INTO userinfo(`username`,`department`) VALUES (?,?),(?,?)",
+		generateInsertSQL(MySQL, "userinfo", columns, 2))
+	assert.Equal(t, `INSERT INTO userinfo("username","department") VALUES ($1,$2),($3,$4)`,
+		generateInsertSQL(Postgres, "userinfo", columns, 2))
+	assert.Equal(t, `INSERT INTO userinfo("username","department") VALUES (?,?),(?,?)`,
+		generateInsertSQL(SQLite, "userinfo", columns, 2))
+
+	assert.Equal(t, "UPDATE userinfo SET `username`=?,`department`=? WHERE `uid`=? AND `version`=?",
+		generateUpdateSQL(MySQL, "userinfo", columns, []string{"uid", "version"}))
+	assert.Equal(t, `UPDATE userinfo SET "username"=$1,"department"=$2 WHERE "uid"=$3 AND "version"=$4`,
+		generateUpdateSQL(Postgres, "userinfo", columns, []string{"uid", "version"}))
+
+	assert.Equal(t, `DELETE FROM userinfo WHERE "uid"=$1 AND "version"=$2`,
+		generateDeleteSQL(Postgres, "userinfo", []string{"uid", "version"}))
+	assert.Equal(t, "DELETE FROM userinfo WHERE `uid` IN (?,?)",
+		generateDeleteInSQL(MySQL, "userinfo", []string{"uid"}, 2, false))
+	assert.Equal(t, `DELETE FROM userinfo WHERE ("tenant","uid") IN (($1,$2),($3,$4))`,
+		generateDeleteInSQL(Postgres, "userinfo", []string{"tenant", "uid"}, 2, true))
+	assert.Equal(t, `DELETE FROM userinfo WHERE ("tenant"=$1 AND "uid"=$2) OR ("tenant"=$3 AND "uid"=$4)`,
+		generateDeleteInSQL(Postgres, "userinfo", []string{"tenant", "uid"}, 2, false))
+
+	assert.Equal(t, ` ON CONFLICT ("uid") DO UPDATE SET "username"=excluded."username","version"=excluded."version" WHERE userinfo."version"<excluded."version"`,
+		Postgres.OnConflict("userinfo", []string{"uid"}, []string{"username"}, "version"))
+	assert.Equal(t, ` ON CONFLICT ("uid") DO NOTHING`,
+		SQLite.OnConflict("userinfo", []string{"uid"}, nil, ""))
+	assert.Equal(t, " ON DUPLICATE KEY UPDATE `username`=VALUES(`username`)",
+		MySQL.OnConflict("userinfo", []string{"uid"}, []string{"username"}, ""))
+	assert.Equal(t, " ON DUPLICATE KEY UPDATE `username`=IF(`version`<VALUES(`version`),VALUES(`username`),`username`),`version`=IF(`version`<VALUES(`version`),VALUES(`version`),`version`)",
+		MySQL.OnConflict("userinfo", []string{"uid"}, []string{"username"}, "version"))
+	assert.Equal(t, " ON DUPLICATE KEY UPDATE `uid`=`uid`",
+		MySQL.OnConflict("userinfo", []string{"uid"}, nil, ""))
+
+	assert.Equal(t, ` RETURNING "uid"`, Postgres.Returning([]string{"uid"}))
+	assert.Equal(t, "", MySQL.Returning([]string{"uid"}))
+	assert.Equal(t, " LIMIT 10 OFFSET 20", Postgres.Limit(10, 20))
+	assert.Equal(t, " LIMIT 10", SQLite.Limit(10, 0))
+
+	assert.Equal(t, "`a``b`", MySQL.Quote("a`b"))
+	assert.Equal(t, `"a""b"`, Postgres.Quote(`a"b`))
+}
+
+func TestDialect_RewriteQuery(t *testing.T) {
+	query, args, err := rewriteQuery(rewriteConfig(Postgres, EmptySliceNull), "select * from userinfo where uid in ? and department = ?", []any{[]int{1, 2}, "dev"})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from userinfo where uid in ($1,$2) and department = $3", query)
+	assert.Equal(t, []any{1, 2, "dev"}, args)
+
+	query, args, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceNull), "select * from userinfo where uid = ?", []any{1})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from userinfo where uid = $1", query)
+	assert.Equal(t, []any{1}, args)
+
+	query, args, err = RewriteQuery("select * from userinfo where uid in ?", []int{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from userinfo where uid in (?,?)", query)
+	assert.Equal(t, []any{1, 2}, args)
+
+	_, _, err = RewriteQuery("select * from userinfo where uid = ? and department = ?", 1)
+	assert.ErrorIs(t, err, ErrPlaceholderMismatch)
+	query, args = RewriteQueryAndArgs("select * from userinfo where uid = ? and department = ?", 1)
+	assert.Equal(t, "select * from userinfo where uid = ? and department = ?", query)
+	assert.Equal(t, []any{1}, args)
+}
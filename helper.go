@@ -1,7 +1,9 @@
 package orm
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"fmt"
 	"github.com/hyperchao/orm/tag"
 	"reflect"
 	"slices"
@@ -20,59 +22,57 @@ const (
 	columnAttrPrimary columnAttr = 1 << iota
 	columnAttrAutoincrement
 	columnAttrOptimisticLock
+	columnAttrNullZero
 )
 
 func (c columnAttr) Has(attr columnAttr) bool {
 	return c&attr != 0
 }
 
+// columnTag is the parsed tag of a field
+type columnTag struct {
+	attrs      columnAttr
+	serializer Serializer
+}
+
+func (t columnTag) Has(attr columnAttr) bool {
+	return t.attrs.Has(attr)
+}
+
 const (
 	separator   = ","
 	placeholder = "?"
-	quote       = "`"
 	equals      = `=`
 )
 
 var (
-	tagParser = tag.NewParser(func(tagValue string) (field string, attributes columnAttr) {
-		// tagValue example. `orm:"id,primary,autoincrement"`
+	tagParser = tag.NewParser(func(tagValue string) (field string, ct columnTag) {
+		// tagValue example. `orm:"id,primary,autoincrement"`, `orm:"settings,json"`
 		parts := strings.Split(tagValue, ",")
 		field = parts[0]
 		for _, attr := range parts[1:] {
-			if strings.TrimSpace(attr) == TagPrimaryKey {
-				attributes |= columnAttrPrimary
+			attr = strings.TrimSpace(attr)
+			if attr == TagPrimaryKey {
+				ct.attrs |= columnAttrPrimary
+			}
+			if attr == TagAutoIncrement {
+				ct.attrs |= columnAttrAutoincrement
 			}
-			if strings.TrimSpace(attr) == TagAutoIncrement {
-				attributes |= columnAttrAutoincrement
+			if attr == TagVersion {
+				ct.attrs |= columnAttrOptimisticLock
 			}
-			if strings.TrimSpace(attr) == TagVersion {
-				attributes |= columnAttrOptimisticLock
+			if attr == TagNullZero {
+				ct.attrs |= columnAttrNullZero
+			}
+			if s := getSerializer(attr); s != nil {
+				ct.serializer = s
 			}
 		}
 		return
 	})
 )
 
-// extract a slice of interfaces from struct for sql.Rows.Scan to use
-func getColumnDest(conf *config, val any, columns []string) []any {
-	if len(columns) == 0 {
-		return nil
-	}
-
-	values := tagParser.Parse(conf.tagName, val)
-
-	r := make([]any, len(columns))
-	for i, col := range columns {
-		if values.Contains(col) {
-			r[i] = values.Get(col).Addr()
-		} else {
-			r[i] = empty{}
-		}
-	}
-	return r
-}
-
-// RewriteQueryAndArgs transform a slice argument to a list of arguments and rewrite the "?" in query to "(?,?,...)"
+// RewriteQuery transform a slice argument to a list of arguments and rewrite the "?" in query to "(?,?,...)"
 // so we can write sql like this:
 //
 //	query := "select * from userinfo where uid in ? and state = ?"
@@ -84,56 +84,178 @@ func getColumnDest(conf *config, val any, columns []string) []any {
 //	args := 1, 2, 3, 1
 //
 // empty slice will rewrite "?" in query to  "(NULL)"
-// take care of the behavior. especially when you use "not in" clause
+// take care of the behavior. especially when you use "not in" clause.
+// see [SetEmptySliceMode] for the alternatives.
+// a slice of tuples is expanded to "((?,?),(?,?),...)", see [Tuples]. []byte is a single value
+//
+// placeholders are written in the style of the default dialect, see [SetDialect].
+// e.g. "?" becomes "$1", "$2", ... for [Postgres]
+//
+// "?" in string literals, quoted identifiers, comments and dollar-quoted strings are not placeholders,
+// and "??" is a literal "?", e.g. for the Postgres jsonb operator "??|".
+// it fails with [ErrPlaceholderMismatch] when the number of placeholders doesn't match the number of args.
+// with an empty slice, it fails with [ErrEmptyInClause] in [EmptySliceError] mode, and [ErrSkipQuery]
+// in [EmptySliceSkip] mode
+func RewriteQuery(query string, args ...any) (rewrittenQuery string, expandedArgs []any, err error) {
+	return rewriteQuery(defaultConfig.Load(), query, args)
+}
+
+// RewriteQueryAndArgs is [RewriteQuery] returning query and args as is when they can't be rewritten.
+//
+// Deprecated: use [RewriteQuery], which reports why they can't be rewritten
 func RewriteQueryAndArgs(query string, args ...any) (rewrittenQuery string, expandedArgs []any) {
-	sliceIndexes := make([]int, 0)
-	for i, arg := range args {
-		if _, ok := arg.(driver.Valuer); ok {
+	rewrittenQuery, expandedArgs, err := RewriteQuery(query, args...)
+	if err != nil {
+		return query, args
+	}
+	return rewrittenQuery, expandedArgs
+}
+
+// rewriteQuery is [RewriteQuery] with conf.
+// ErrSkipQuery is returned when the query must not be executed
+func rewriteQuery(conf *config, query string, args []any) (string, []any, error) {
+	d := conf.dialect
+	// driver named args aren't bound to "?"
+	var namedArgs []any
+	if slices.ContainsFunc(args, isNamedArg) {
+		namedArgs = slices.DeleteFunc(slices.Clone(args), func(arg any) bool { return !isNamedArg(arg) })
+		args = slices.DeleteFunc(slices.Clone(args), isNamedArg)
+	}
+
+	w := newSQLWriter(d)
+	w.Grow(len(query))
+	var expandedArgs []any
+	placeholders := 0
+	for i := 0; i < len(query); {
+		if end := skipLiteral(d, query, i); end > i {
+			w.WriteString(query[i:end])
+			i = end
 			continue
 		}
-		rt := reflect.TypeOf(arg)
-		if rt != nil && rt.Kind() == reflect.Slice {
-			sliceIndexes = append(sliceIndexes, i)
+
+		if query[i] != '?' {
+			w.WriteByte(query[i])
+			i++
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == '?' {
+			w.WriteByte('?')
+			i += 2
+			continue
 		}
+
+		if placeholders < len(args) {
+			var err error
+			if !isEmptySlice(args[placeholders]) {
+				expandedArgs, err = writeArg(w, conf, expandedArgs, args[placeholders])
+			} else {
+				err = writeEmptySlice(w, conf.emptySlice)
+			}
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		placeholders++
+		i++
 	}
 
-	if len(sliceIndexes) == 0 {
-		return query, args
+	if placeholders != len(args) {
+		return "", nil, fmt.Errorf("%w: %d placeholders for %d args", ErrPlaceholderMismatch, placeholders, len(args))
 	}
+	return w.String(), append(expandedArgs, namedArgs...), nil
+}
 
-	queryParts := strings.Split(query, placeholder)
-	sb := strings.Builder{}
-	for idx, part := range queryParts {
-		sb.WriteString(part)
-		if idx != len(queryParts)-1 {
-			_, found := slices.BinarySearch(sliceIndexes, idx)
-			if found {
-				sv := reflect.ValueOf(args[idx])
-				if sv.Len() == 0 {
-					sb.WriteString("(NULL)")
-				} else {
-					sb.WriteString("(")
-					for i := 0; i < sv.Len(); i++ {
-						expandedArgs = append(expandedArgs, sv.Index(i).Interface())
-						sb.WriteString(placeholder)
-						if i != sv.Len()-1 {
-							sb.WriteString(",")
-						}
-					}
-					sb.WriteString(")")
-				}
-			} else {
-				expandedArgs = append(expandedArgs, args[idx])
-				sb.WriteString(placeholder)
+func isNamedArg(arg any) bool {
+	_, ok := arg.(sql.NamedArg)
+	return ok
+}
+
+func isEmptySlice(arg any) bool {
+	if tuples, ok := arg.(TuplesArg); ok {
+		return len(tuples.rows) == 0
+	}
+	return isSliceArg(arg) && reflect.ValueOf(arg).Len() == 0
+}
+
+// isSliceArg reports whether arg is a list of values, []byte and driver.Valuer are single values
+func isSliceArg(arg any) bool {
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+	rt := reflect.TypeOf(arg)
+	return rt != nil && rt.Kind() == reflect.Slice && rt.Elem().Kind() != reflect.Uint8
+}
+
+// writeEmptySlice write the placeholder of an empty slice according to mode
+func writeEmptySlice(w *sqlWriter, mode EmptySliceMode) error {
+	switch mode {
+	case EmptySliceError:
+		return ErrEmptyInClause
+	case EmptySliceSkip:
+		return ErrSkipQuery
+	case EmptySliceRewrite:
+		return rewriteEmptyIn(w)
+	default:
+		w.WriteString("(NULL)")
+		return nil
+	}
+}
+
+// writeArg write the placeholders of arg, a non-empty slice is expanded to "(?,?,...)", and tuples
+// to "((?,?),(?,?),...)". see [Tuples]
+func writeArg(w *sqlWriter, conf *config, args []any, arg any) ([]any, error) {
+	if rows, ok := tupleRows(conf, arg); ok {
+		return writeTuples(w, conf, args, rows)
+	}
+	if !isSliceArg(arg) {
+		w.writePlaceholder()
+		return append(args, arg), nil
+	}
+
+	sv := reflect.ValueOf(arg)
+	w.WriteString("(")
+	for i := 0; i < sv.Len(); i++ {
+		if i > 0 {
+			w.WriteString(separator)
+		}
+		args = append(args, sv.Index(i).Interface())
+		w.writePlaceholder()
+	}
+	w.WriteString(")")
+	return args, nil
+}
+
+// prepareQuery returns the query and args sent to the database, after binding named parameters
+// and rewriting when enabled. ErrSkipQuery is returned when the query must not be executed
+func prepareQuery(conf *config, query string, args []any) (string, []any, error) {
+	if len(args) == 1 {
+		if named, ok := args[0].(NamedArgs); ok {
+			var err error
+			if query, args, err = bindNamed(conf, query, named); err != nil {
+				return "", nil, err
 			}
+			return rewriteQuery(conf, query, args)
 		}
 	}
+	if conf.rewriteQuery {
+		return rewriteQuery(conf, query, args)
+	}
+	return query, args, nil
+}
 
-	rewrittenQuery = sb.String()
-	return
+// columnArg returns the arg written to the database for value.
+// zero value of a "nullzero" tagged column is written as NULL, serializer columns are marshalled
+func columnArg(value tag.Value[columnTag]) any {
+	if value.Meta().Attrs().Has(columnAttrNullZero) && value.Value().IsZero() {
+		return nil
+	}
+	if s := value.Meta().Attrs().serializer; s != nil {
+		return serialized{serializer: s, value: value.Interface()}
+	}
+	return value.Interface()
 }
 
-func parseInsertColumnsAndArgs(values tag.Values[columnAttr]) (columns []string, autoincrement string, args []any) {
+func parseInsertColumnsAndArgs(values tag.Values[columnTag]) (columns []string, autoincrement string, args []any) {
 	if values.Len() == 0 {
 		return
 	}
@@ -146,13 +268,15 @@ func parseInsertColumnsAndArgs(values tag.Values[columnAttr]) (columns []string,
 			continue
 		}
 		columns = append(columns, field)
-		args = append(args, value.Interface())
+		args = append(args, columnArg(value))
 	}
 
 	return
 }
 
-func parseUpdateColumnsAndArgs(conf *config, values tag.Values[columnAttr]) (columns, wheres []string, args, wheresArgs []any, versionValue tag.Value[columnAttr]) {
+// parseUpdateColumnsAndArgs collect the columns to update, restricted by the [Columns] and [Omit] options,
+// and to the changed columns when snap is not nil
+func parseUpdateColumnsAndArgs(conf *config, snap *Snapshot, values tag.Values[columnTag]) (columns, wheres []string, args, wheresArgs []any, versionValue tag.Value[columnTag]) {
 	if values.Len() == 0 {
 		return
 	}
@@ -173,10 +297,92 @@ func parseUpdateColumnsAndArgs(conf *config, values tag.Values[columnAttr]) (col
 			args = append(args, value.Value().Int()+1)
 			continue
 		}
+		if !isUpdateColumn(conf, snap, field, value) {
+			continue
+		}
+		columns = append(columns, field)
+		args = append(args, columnArg(value))
+	}
+
+	return
+}
+
+func isUpdateColumn(conf *config, snap *Snapshot, field string, value tag.Value[columnTag]) bool {
+	if len(conf.columns) > 0 && !slices.Contains(conf.columns, field) {
+		return false
+	}
+	if slices.Contains(conf.omit, field) {
+		return false
+	}
+	return snap.changed(field, value)
+}
+
+// parseUpsertColumnsAndArgs returns the inserted columns and their args, the conflict target,
+// the columns to update on conflict and the version column guarding the update
+func parseUpsertColumnsAndArgs(conf *config, values tag.Values[columnTag]) (columns []string, args []any, conflict, update []string, version string) {
+	conflict = conf.conflictColumns
+	if len(conflict) == 0 {
+		conflict = parsePrimaryColumns(values)
+	}
+
+	for field, value := range values.Iter() {
+		if value.Meta().Attrs().Has(columnAttrAutoincrement) && !slices.Contains(conflict, field) {
+			continue
+		}
 		columns = append(columns, field)
-		args = append(args, value.Interface())
+		args = append(args, columnArg(value))
+
+		if conf.enableOptimisticLock && value.Meta().Attrs().Has(columnAttrOptimisticLock) && isCorrectVersionFieldType(value.Meta().Type()) {
+			version = field
+		}
 	}
 
+	update = conf.upsertColumns
+	if len(update) == 0 {
+		update = columns
+	}
+	update = slices.DeleteFunc(slices.Clone(update), func(col string) bool {
+		return col == version || slices.Contains(conflict, col)
+	})
+
+	return
+}
+
+// hasZeroAutoIncrement reports whether the "autoincrement" column is part of conflict and holds the zero value
+func hasZeroAutoIncrement(values tag.Values[columnTag], conflict []string) bool {
+	for field, value := range values.Iter() {
+		if value.Meta().Attrs().Has(columnAttrAutoincrement) && slices.Contains(conflict, field) {
+			return value.Value().IsZero()
+		}
+	}
+	return false
+}
+
+func parseDeleteColumnsAndArgs(conf *config, values tag.Values[columnTag]) (wheres []string, wheresArgs []any, versionValue tag.Value[columnTag]) {
+	for field, value := range values.Iter() {
+		if value.Meta().Attrs().Has(columnAttrPrimary) {
+			wheres = append(wheres, field)
+			wheresArgs = append(wheresArgs, value.Interface())
+			continue
+		}
+		if conf.enableOptimisticLock && value.Meta().Attrs().Has(columnAttrOptimisticLock) && isCorrectVersionFieldType(value.Meta().Type()) {
+			versionValue = value
+		}
+	}
+	if versionValue != nil && len(wheres) > 0 {
+		wheres = append(wheres, versionValue.Meta().Name())
+		wheresArgs = append(wheresArgs, versionValue.Interface())
+	}
+
+	return
+}
+
+func parsePrimaryColumns(values tag.Values[columnTag]) (columns []string) {
+	for field, value := range values.Iter() {
+		if value.Meta().Attrs().Has(columnAttrPrimary) {
+			columns = append(columns, field)
+		}
+	}
 	return
 }
 
@@ -184,95 +390,162 @@ func isCorrectVersionFieldType(t reflect.Type) bool {
 	return t.Kind() == reflect.Int || t.Kind() == reflect.Int8 || t.Kind() == reflect.Int16 || t.Kind() == reflect.Int32 || t.Kind() == reflect.Int64
 }
 
-func generateInsertSQL(tableName string, columns []string, count int) string {
-	sb := strings.Builder{}
-	sb.WriteString("INSERT INTO ")
-	sb.WriteString(tableName)
-	writeSQLColumns(&sb, columns)
-	sb.WriteString(" VALUES ")
-	writeSQLPlaceholders(&sb, len(columns))
+// sqlWriter builds a statement in the syntax of a dialect.
+// placeholders are numbered in the order they are written
+type sqlWriter struct {
+	strings.Builder
+	dialect        Dialect
+	args           int
+	placeholderEnd int // end of the last placeholder written
+}
+
+func newSQLWriter(d Dialect) *sqlWriter {
+	return &sqlWriter{dialect: d}
+}
+
+func (w *sqlWriter) writePlaceholder() {
+	w.args++
+	w.WriteString(w.dialect.Placeholder(w.args))
+	w.placeholderEnd = w.Len()
+}
+
+func (w *sqlWriter) writeIdentifier(name string) {
+	w.WriteString(w.dialect.Quote(name))
+}
+
+func generateInsertSQL(d Dialect, tableName string, columns []string, count int) string {
+	w := newSQLWriter(d)
+	w.WriteString("INSERT INTO ")
+	w.WriteString(tableName)
+	writeSQLColumns(w, columns)
+	w.WriteString(" VALUES ")
+	writeSQLPlaceholders(w, len(columns))
 	for count > 1 {
-		sb.WriteString(",")
-		writeSQLPlaceholders(&sb, len(columns))
+		w.WriteString(separator)
+		writeSQLPlaceholders(w, len(columns))
 		count--
 	}
-	return sb.String()
+	return w.String()
 }
 
-func writeSQLColumns(sb *strings.Builder, slice []string) {
+func writeSQLColumns(w *sqlWriter, slice []string) {
 	if len(slice) == 0 {
 		return
 	}
-	sb.WriteString("(")
-	sb.WriteString(slice[0])
+	w.WriteString("(")
+	w.writeIdentifier(slice[0])
 	for i := 1; i < len(slice); i++ {
-		sb.WriteString(separator)
-		sb.WriteString(quote)
-		sb.WriteString(slice[i])
-		sb.WriteString(quote)
+		w.WriteString(separator)
+		w.writeIdentifier(slice[i])
 	}
-	sb.WriteString(")")
+	w.WriteString(")")
 }
 
-func writeSQLPlaceholders(sb *strings.Builder, n int) {
+func writeSQLPlaceholders(w *sqlWriter, n int) {
 	if n <= 0 {
 		return
 	}
-	sb.WriteString("(")
-	sb.WriteString(placeholder)
+	w.WriteString("(")
+	w.writePlaceholder()
 	for i := 1; i < n; i++ {
-		sb.WriteString(separator)
-		sb.WriteString(placeholder)
+		w.WriteString(separator)
+		w.writePlaceholder()
 	}
-	sb.WriteString(")")
+	w.WriteString(")")
 }
 
-func generateUpdateSQL(tableName string, columns, wheres []string) string {
-	sb := strings.Builder{}
-	sb.WriteString("UPDATE ")
-	sb.WriteString(tableName)
-	writeUpdateSetSQL(&sb, columns)
-	writeUpdateWhereSQL(&sb, wheres)
-	return sb.String()
+func generateUpdateSQL(d Dialect, tableName string, columns, wheres []string) string {
+	w := newSQLWriter(d)
+	w.WriteString("UPDATE ")
+	w.WriteString(tableName)
+	writeUpdateSetSQL(w, columns)
+	writeWhereSQL(w, wheres)
+	return w.String()
 }
 
-func writeUpdateSetSQL(sb *strings.Builder, columns []string) {
+func writeUpdateSetSQL(w *sqlWriter, columns []string) {
 	if len(columns) == 0 {
 		return
 	}
-	sb.WriteString(" SET ")
-	sb.WriteString(quote)
-	sb.WriteString(columns[0])
-	sb.WriteString(quote)
-	sb.WriteString(equals)
-	sb.WriteString(placeholder)
+	w.WriteString(" SET ")
+	w.writeIdentifier(columns[0])
+	w.WriteString(equals)
+	w.writePlaceholder()
 
 	for i := 1; i < len(columns); i++ {
-		sb.WriteString(separator)
-		sb.WriteString(quote)
-		sb.WriteString(columns[i])
-		sb.WriteString(quote)
-		sb.WriteString(equals)
-		sb.WriteString(placeholder)
+		w.WriteString(separator)
+		w.writeIdentifier(columns[i])
+		w.WriteString(equals)
+		w.writePlaceholder()
 	}
 }
 
-func writeUpdateWhereSQL(sb *strings.Builder, wheres []string) {
+func writeWhereSQL(w *sqlWriter, wheres []string) {
 	if len(wheres) == 0 {
 		return
 	}
-	sb.WriteString(" WHERE ")
-	sb.WriteString(quote)
-	sb.WriteString(wheres[0])
-	sb.WriteString(quote)
-	sb.WriteString(equals)
-	sb.WriteString(placeholder)
+	w.WriteString(" WHERE ")
+	w.writeIdentifier(wheres[0])
+	w.WriteString(equals)
+	w.writePlaceholder()
 	for i := 1; i < len(wheres); i++ {
-		sb.WriteString(" AND ")
-		sb.WriteString(quote)
-		sb.WriteString(wheres[i])
-		sb.WriteString(quote)
-		sb.WriteString(equals)
-		sb.WriteString(placeholder)
+		w.WriteString(" AND ")
+		w.writeIdentifier(wheres[i])
+		w.WriteString(equals)
+		w.writePlaceholder()
+	}
+}
+
+func generateDeleteSQL(d Dialect, tableName string, wheres []string) string {
+	w := newSQLWriter(d)
+	w.WriteString("DELETE FROM ")
+	w.WriteString(tableName)
+	writeWhereSQL(w, wheres)
+	return w.String()
+}
+
+// generateDeleteInSQL generate "DELETE FROM table WHERE key IN (?,?,...)" for count rows.
+// composite keys are written as row values: "WHERE (key1,key2) IN ((?,?),(?,?),...)",
+// or as "WHERE (key1=? AND key2=?) OR (key1=? AND key2=?) ..." without rowValues. see [SetRowValues]
+func generateDeleteInSQL(d Dialect, tableName string, keys []string, count int, rowValues bool) string {
+	w := newSQLWriter(d)
+	w.WriteString("DELETE FROM ")
+	w.WriteString(tableName)
+	w.WriteString(" WHERE ")
+	if len(keys) > 1 && !rowValues {
+		for i := 0; i < count; i++ {
+			if i > 0 {
+				w.WriteString(" OR ")
+			}
+			w.WriteString("(")
+			for j, key := range keys {
+				if j > 0 {
+					w.WriteString(" AND ")
+				}
+				w.writeIdentifier(key)
+				w.WriteString(equals)
+				w.writePlaceholder()
+			}
+			w.WriteString(")")
+		}
+		return w.String()
+	}
+	if len(keys) == 1 {
+		w.writeIdentifier(keys[0])
+	} else {
+		writeSQLColumns(w, keys)
+	}
+	w.WriteString(" IN (")
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			w.WriteString(separator)
+		}
+		if len(keys) == 1 {
+			w.writePlaceholder()
+		} else {
+			writeSQLPlaceholders(w, len(keys))
+		}
 	}
+	w.WriteString(")")
+	return w.String()
 }
@@ -0,0 +1,78 @@
+package orm
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeInserter is called before the model is inserted by [InsertOne], [InsertMany], [UpsertOne] and [UpsertMany].
+// changes made to the model are written. an error aborts the insert
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is called after the model is inserted, the autoincrement column is already set
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is called before the model is updated by [UpdateOne].
+// it runs before the optimistic lock version bump, so the model still holds the current version.
+// changes made to the model are written. an error aborts the update
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is called after the model is updated, the version is already bumped
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// AfterScanner is called after a row is scanned into the model by [GetOne], [GetMany] and [GetIter].
+// an error aborts the query
+type AfterScanner interface {
+	AfterScan(ctx context.Context) error
+}
+
+// hooks are implemented by the model, or by the pointer to it when rows are scanned into *T
+func hookTarget[T any](obj *T) any {
+	if reflect.TypeFor[T]().Kind() == reflect.Pointer {
+		return *obj
+	}
+	return obj
+}
+
+func beforeInsert(ctx context.Context, data any) error {
+	if h, ok := data.(BeforeInserter); ok {
+		return h.BeforeInsert(ctx)
+	}
+	return nil
+}
+
+func afterInsert(ctx context.Context, data any) error {
+	if h, ok := data.(AfterInserter); ok {
+		return h.AfterInsert(ctx)
+	}
+	return nil
+}
+
+func beforeUpdate(ctx context.Context, data any) error {
+	if h, ok := data.(BeforeUpdater); ok {
+		return h.BeforeUpdate(ctx)
+	}
+	return nil
+}
+
+func afterUpdate(ctx context.Context, data any) error {
+	if h, ok := data.(AfterUpdater); ok {
+		return h.AfterUpdate(ctx)
+	}
+	return nil
+}
+
+func afterScan(ctx context.Context, data any) error {
+	if h, ok := data.(AfterScanner); ok {
+		return h.AfterScan(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errInvalidUsername = errors.New("invalid username")
+
+type HookedUserInfo struct {
+	UserInfo
+	Events []string
+}
+
+func (u *HookedUserInfo) BeforeInsert(context.Context) error {
+	if u.Username == "" {
+		return errInvalidUsername
+	}
+	now := time.Now()
+	u.CreateAt = &now
+	u.Events = append(u.Events, "BeforeInsert")
+	return nil
+}
+
+func (u *HookedUserInfo) AfterInsert(context.Context) error {
+	u.Events = append(u.Events, "AfterInsert")
+	return nil
+}
+
+func (u *HookedUserInfo) BeforeUpdate(context.Context) error {
+	u.Username = strings.ToLower(u.Username)
+	u.Events = append(u.Events, "BeforeUpdate")
+	return nil
+}
+
+func (u *HookedUserInfo) AfterUpdate(context.Context) error {
+	u.Events = append(u.Events, "AfterUpdate")
+	return nil
+}
+
+func (u *HookedUserInfo) AfterScan(context.Context) error {
+	u.Events = append(u.Events, "AfterScan")
+	return nil
+}
+
+func Test_Hooks(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	err := InsertOne(ctx, db, "userinfo", &HookedUserInfo{})
+	assert.True(t, errors.Is(err, errInvalidUsername))
+	assert.Equal(t, 0, countUsers(t, db))
+
+	userinfo := &HookedUserInfo{UserInfo: UserInfo{Username: "astaxie"}}
+	err = InsertOne(ctx, db, "userinfo", userinfo)
+	assert.Nil(t, err)
+	assert.NotNil(t, userinfo.CreateAt)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, userinfo.Events)
+
+	userinfo.Username = "ASTAXIE2"
+	err = UpdateOne(ctx, db, "userinfo", userinfo, EnableOptimisticLock(true))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), userinfo.Version)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert", "BeforeUpdate", "AfterUpdate"}, userinfo.Events)
+
+	u, err := GetOne[HookedUserInfo](ctx, db, "select * from userinfo")
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie2", u.Username)
+	assert.NotNil(t, u.CreateAt)
+	assert.Equal(t, []string{"AfterScan"}, u.Events)
+
+	users, err := GetMany[*HookedUserInfo](ctx, db, "select * from userinfo")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AfterScan"}, (*users[0]).Events)
+
+	err = InsertMany(ctx, db, "userinfo", []*HookedUserInfo{{UserInfo: UserInfo{Username: "ok"}}, {}})
+	assert.True(t, errors.Is(err, errInvalidUsername))
+	assert.Equal(t, 1, countUsers(t, db))
+}
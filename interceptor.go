@@ -0,0 +1,109 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// QueryInfo describes a statement sent to the database.
+// Query and Args are final, i.e. after rewriting. the other fields are populated when the statement finishes
+type QueryInfo struct {
+	Query string
+	Args  []any
+	// Duration of the statement. for queries, it doesn't include reading the rows
+	Duration time.Duration
+	// RowsAffected is -1 for queries, and when the driver doesn't report it
+	RowsAffected int64
+	Err          error
+}
+
+// Interceptor wraps the execution of a statement. it must call next to execute the statement,
+// and usually returns the error of next. interceptors are called in the order they are added
+//
+//	func(ctx context.Context, info *orm.QueryInfo, next func(context.Context) error) error {
+//		ctx, span := tracer.Start(ctx, "sql")
+//		defer span.End()
+//		err := next(ctx)
+//		span.SetAttributes(attribute.String("db.statement", info.Query))
+//		return err
+//	}
+type Interceptor func(ctx context.Context, info *QueryInfo, next func(ctx context.Context) error) error
+
+// SlowQueryLog returns an interceptor logging statements taking at least threshold with logger.
+// [slog.Default] is used when logger is nil
+func SlowQueryLog(logger *slog.Logger, threshold time.Duration) Interceptor {
+	return func(ctx context.Context, info *QueryInfo, next func(ctx context.Context) error) error {
+		err := next(ctx)
+		if info.Duration >= threshold {
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			l.WarnContext(ctx, "slow query",
+				slog.String("query", info.Query),
+				slog.Any("args", info.Args),
+				slog.Duration("duration", info.Duration),
+				slog.Int64("rows_affected", info.RowsAffected),
+				slog.Any("error", err))
+		}
+		return err
+	}
+}
+
+// queryContext run db.QueryContext through the interceptors of conf
+func queryContext(ctx context.Context, conf *config, db DB, query string, args ...any) (*sql.Rows, error) {
+	if len(conf.interceptors) == 0 {
+		return db.QueryContext(ctx, query, args...)
+	}
+
+	var rows *sql.Rows
+	err := intercept(ctx, conf, query, args, func(ctx context.Context, info *QueryInfo) (err error) {
+		rows, err = db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// execContext run db.ExecContext through the interceptors of conf
+func execContext(ctx context.Context, conf *config, db DB, query string, args ...any) (sql.Result, error) {
+	if len(conf.interceptors) == 0 {
+		return db.ExecContext(ctx, query, args...)
+	}
+
+	var result sql.Result
+	err := intercept(ctx, conf, query, args, func(ctx context.Context, info *QueryInfo) (err error) {
+		result, err = db.ExecContext(ctx, query, args...)
+		if err == nil {
+			if rowsAffected, err := result.RowsAffected(); err == nil {
+				info.RowsAffected = rowsAffected
+			}
+		}
+		return err
+	})
+	return result, err
+}
+
+func intercept(ctx context.Context, conf *config, query string, args []any, exec func(ctx context.Context, info *QueryInfo) error) error {
+	info := &QueryInfo{
+		Query:        query,
+		Args:         args,
+		RowsAffected: -1,
+	}
+
+	next := func(ctx context.Context) error {
+		start := time.Now()
+		err := exec(ctx, info)
+		info.Duration = time.Since(start)
+		info.Err = err
+		return err
+	}
+	for i := len(conf.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := conf.interceptors[i], next
+		next = func(ctx context.Context) error {
+			return interceptor(ctx, info, inner)
+		}
+	}
+	return next(ctx)
+}
@@ -0,0 +1,64 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func Test_Interceptor(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	var order []string
+	var infos []*QueryInfo
+	record := func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		order = append(order, "outer")
+		err := next(ctx)
+		infos = append(infos, info)
+		return err
+	}
+	inner := func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		order = append(order, "inner")
+		return next(ctx)
+	}
+
+	err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie"}, WithInterceptors(record, inner))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+	assert.Equal(t, 1, len(infos))
+	assert.Contains(t, infos[0].Query, "INSERT INTO userinfo")
+	assert.Equal(t, int64(1), infos[0].RowsAffected)
+	assert.Nil(t, infos[0].Err)
+
+	_, err = GetMany[UserInfo](ctx, db, "select * from userinfo where uid in ?", []int{1, 2}, WithInterceptors(record))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(infos))
+	assert.Equal(t, "select * from userinfo where uid in (?,?)", infos[1].Query)
+	assert.Equal(t, []any{1, 2}, infos[1].Args)
+	assert.Equal(t, int64(-1), infos[1].RowsAffected)
+
+	_, err = GetMany[UserInfo](ctx, db, "select * from not_exist", WithInterceptors(record))
+	assert.NotNil(t, err)
+	assert.Equal(t, err, infos[2].Err)
+}
+
+func Test_SlowQueryLog(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	_, err := GetMany[UserInfo](ctx, db, "select * from userinfo", WithInterceptors(SlowQueryLog(logger, time.Hour)))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, buf.Len())
+
+	_, err = GetMany[UserInfo](ctx, db, "select * from userinfo", WithInterceptors(SlowQueryLog(logger, 0)))
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "slow query")
+	assert.Contains(t, buf.String(), "select * from userinfo")
+}
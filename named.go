@@ -0,0 +1,104 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// NamedArgs are the named parameters of a query, see [Named]
+type NamedArgs struct {
+	params any
+}
+
+// Named bind the ":name" parameters of a query to params, which is a map with string keys,
+// or a struct whose tagged fields are the parameters. a name can be used several times, and a slice
+// is expanded like a "?" argument. see [RewriteQuery]
+//
+//	users, err := orm.GetMany[UserInfo](ctx, db,
+//		"select * from userinfo where department = :dept and uid in :uids",
+//		orm.Named(map[string]any{"dept": "dev", "uids": uids}))
+//
+// "@name" is a parameter as well when the dialect allows it, e.g. [SQLite], but not [MySQL] where it is
+// a user variable. see [Dialect.NamedPrefixes]. names in string literals, quoted identifiers and comments
+// are left untouched, so are "::" casts and "@@" variables. it must be the only argument, besides options,
+// and can't be mixed with "?"
+func Named(params any) NamedArgs {
+	return NamedArgs{params: params}
+}
+
+// bindNamed rewrite the named parameters in query to "?", and returns their values in order
+func bindNamed(conf *config, query string, named NamedArgs) (string, []any, error) {
+	lookup, err := namedLookup(conf, named.params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	prefixes := conf.dialect.NamedPrefixes()
+	var args []any
+	sb := strings.Builder{}
+	sb.Grow(len(query))
+	for i := 0; i < len(query); {
+		if end := skipLiteral(conf.dialect, query, i); end > i {
+			sb.WriteString(query[i:end])
+			i = end
+			continue
+		}
+
+		c := query[i]
+		if (c == ':' || c == '@') && i+1 < len(query) && query[i+1] == c {
+			// "::" cast or "@@" variable
+			sb.WriteString(query[i : i+2])
+			i += 2
+			continue
+		}
+		if strings.IndexByte(prefixes, c) >= 0 && i+1 < len(query) && isNameStart(query[i+1]) && (i == 0 || !isNamePart(query[i-1])) {
+			end := i + 2
+			for end < len(query) && isNamePart(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("named parameter %q not found", name)
+			}
+			args = append(args, value)
+			sb.WriteString(placeholder)
+			i = end
+			continue
+		}
+
+		sb.WriteByte(c)
+		i++
+	}
+	return sb.String(), args, nil
+}
+
+// namedLookup returns the function getting the value of a named parameter
+func namedLookup(conf *config, params any) (func(name string) (any, bool), error) {
+	rv := reflect.ValueOf(params)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		return func(name string) (any, bool) {
+			v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !v.IsValid() {
+				return nil, false
+			}
+			return v.Interface(), true
+		}, nil
+	case rv.Kind() == reflect.Struct:
+		values := tagParser.Parse(conf.tagName, params)
+		return func(name string) (any, bool) {
+			if !values.Contains(name) {
+				return nil, false
+			}
+			return columnArg(values.Get(name)), true
+		}, nil
+	default:
+		return nil, fmt.Errorf("named parameters must be a map with string keys or a struct, got %T", params)
+	}
+}
@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Named(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertMany(ctx, db, "userinfo", []*UserInfo{{Username: "a", Department: "dev"}, {Username: "b", Department: "dev"}, {Username: "c", Department: "ops"}})
+	assert.Nil(t, err)
+
+	users, err := GetMany[UserInfo](ctx, db, "select * from userinfo where department = :dept and uid in :uids order by uid",
+		Named(map[string]any{"dept": "dev", "uids": []int64{1, 3}}))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "a", users[0].Username)
+
+	user, err := GetOne[UserInfo](ctx, db, "select * from userinfo where username = @username or department = @username",
+		Named(&UserInfo{Username: "c"}), WithDialect(SQLite))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), user.Uid)
+
+	_, err = GetMany[UserInfo](ctx, db, "select * from userinfo where uid = :uid", Named(map[string]any{}))
+	assert.NotNil(t, err)
+	_, err = GetMany[UserInfo](ctx, db, "select * from userinfo where uid = :uid", Named(1))
+	assert.NotNil(t, err)
+}
+
+func Test_BindNamed(t *testing.T) {
+	conf := *defaultConfig.Load()
+	conf.dialect = Postgres
+	query, args, err := prepareQuery(&conf, `select a::text, '@x :y', "b:c" from t -- :z
+where d = :d and e in :e and f = :d /* @g */ and @@session.h = 1`,
+		[]any{Named(map[string]any{"d": 1, "e": []string{"x", "y"}})})
+	assert.Nil(t, err)
+	assert.Equal(t, `select a::text, '@x :y', "b:c" from t -- :z
+where d = $1 and e in ($2,$3) and f = $4 /* @g */ and @@session.h = 1`, query)
+	assert.Equal(t, []any{1, "x", "y", 1}, args)
+}
+
+func Test_BindNamed_UserVariables(t *testing.T) {
+	conf := *defaultConfig.Load()
+	conf.dialect = MySQL
+	query, args, err := prepareQuery(&conf, "select @rn := @rn + 1 as rn, uid from userinfo, (select @rn := 0) r where department = :dept",
+		[]any{Named(map[string]any{"dept": "dev"})})
+	assert.Nil(t, err)
+	assert.Equal(t, "select @rn := @rn + 1 as rn, uid from userinfo, (select @rn := 0) r where department = ?", query)
+	assert.Equal(t, []any{"dev"}, args)
+
+	conf.dialect = SQLite
+	query, args, err = prepareQuery(&conf, "select * from userinfo where username = @name or department = :name",
+		[]any{Named(map[string]any{"name": "a"})})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from userinfo where username = ? or department = ?", query)
+	assert.Equal(t, []any{"a", "a"}, args)
+}
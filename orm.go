@@ -3,13 +3,46 @@ package orm
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/hyperchao/orm/tag"
+	"iter"
+	"reflect"
+	"slices"
+	"sync"
 )
 
 var (
 	ErrConcurrencyUpdate = fmt.Errorf("concurrency update")
+	ErrMissingPrimaryKey = fmt.Errorf("missing primary key")
+	// ErrInsertIDUnavailable is returned when the ids generated by a multi-row insert can not be determined,
+	// and they are required. see [SetRequireInsertIDs]
+	ErrInsertIDUnavailable = fmt.Errorf("insert id unavailable")
+	// ErrStatementTooLarge is returned when a single row exceeds the max params or the max statement bytes
+	ErrStatementTooLarge = fmt.Errorf("statement too large")
+	// ErrPlaceholderMismatch is returned when the number of "?" in a query doesn't match the number of args
+	ErrPlaceholderMismatch = fmt.Errorf("placeholder mismatch")
+	// ErrEmptyInClause is returned for an empty slice arg in [EmptySliceError] mode
+	ErrEmptyInClause = fmt.Errorf("empty in clause")
+	// ErrSkipQuery is returned by [RewriteQuery] when the query returns no row and must not be executed.
+	// the functions of the package don't execute it and return no row instead. see [EmptySliceSkip]
+	ErrSkipQuery = fmt.Errorf("skip query")
 )
 
+// ColumnMismatchError describes the differences between the columns of a result set and the fields of a model.
+// it is returned in strict mode, see [Strict] and [OnColumnMismatch]
+type ColumnMismatchError struct {
+	Type reflect.Type
+	// Unmapped are the result columns without field
+	Unmapped []string
+	// Missing are the fields absent from the result columns
+	Missing []string
+}
+
+func (e *ColumnMismatchError) Error() string {
+	return fmt.Sprintf("columns mismatch %s: unmapped columns %v, missing columns %v", e.Type, e.Unmapped, e.Missing)
+}
+
 var (
 	_ DB = (*sql.DB)(nil)
 	_ DB = (*sql.Tx)(nil)
@@ -20,20 +53,26 @@ type DB interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
-// GetOne execute query and get one result
-// query and args may be rewritten. see [RewriteQueryAndArgs] for detail
+// GetOne execute query and get one result.
+// T is usually a struct whose tagged fields receive the columns. a single column can be scanned into a
+// basic type, time.Time or a [sql.Scanner]. a row can be scanned into map[string]any or []any too.
+// query and args may be rewritten. see [RewriteQuery] for detail
 func GetOne[T any](ctx context.Context, db DB, query string, args ...any) (*T, error) {
-	conf := defaultConfig
+	conf, db := configOf(db)
 	args, opts := parseArgs(args...)
 	for _, opt := range opts {
 		opt(&conf)
 	}
 
-	if conf.rewriteQuery {
-		query, args = RewriteQueryAndArgs(query, args...)
+	query, args, err := prepareQuery(&conf, query, args)
+	if errors.Is(err, ErrSkipQuery) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := db.QueryContext(ctx, query, args...)
+	rows, err := queryContext(ctx, &conf, db, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,69 +82,166 @@ func GetOne[T any](ctx context.Context, db DB, query string, args ...any) (*T, e
 		return nil, nil
 	}
 
-	cols, err := rows.Columns()
+	scanner, err := newRowScanner[T](ctx, &conf, rows)
 	if err != nil {
 		return nil, err
 	}
 
-	var obj T
-	dest := getColumnDest(&conf, &obj, cols)
-	err = rows.Scan(dest...)
-	if err != nil {
-		return nil, err
-	}
-
-	return &obj, nil
+	return scanner.scan(ctx, rows)
 }
 
 // GetMany execute query and get all result
-// query and args may be rewritten. see [RewriteQueryAndArgs] for detail
+// query and args may be rewritten. see [RewriteQuery] for detail
 func GetMany[T any](ctx context.Context, db DB, query string, args ...any) ([]*T, error) {
-	conf := defaultConfig
+	conf, db := configOf(db)
 	args, opts := parseArgs(args...)
 	for _, opt := range opts {
 		opt(&conf)
 	}
 
-	if conf.rewriteQuery {
-		query, args = RewriteQueryAndArgs(query, args...)
+	query, args, err := prepareQuery(&conf, query, args)
+	if errors.Is(err, ErrSkipQuery) {
+		return make([]*T, 0), nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := db.QueryContext(ctx, query, args...)
+	rows, err := queryContext(ctx, &conf, db, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	cols, err := rows.Columns()
+	scanner, err := newRowScanner[T](ctx, &conf, rows)
 	if err != nil {
 		return nil, err
 	}
 
 	data := make([]*T, 0)
 	for rows.Next() {
-		var obj T
-		dest := getColumnDest(&conf, &obj, cols)
-		err = rows.Scan(dest...)
+		obj, err := scanner.scan(ctx, rows)
 		if err != nil {
 			return nil, err
 		}
-		data = append(data, &obj)
+		data = append(data, obj)
+	}
+
+	return data, nil
+}
+
+// GetIter execute query and iterate the result lazily, which keeps memory flat for large result sets.
+// the query is executed when the iteration starts, and the rows are closed when it ends, even if it
+// breaks early. an error stops the iteration after being yielded.
+// query and args may be rewritten. see [RewriteQuery] for detail
+//
+//	for user, err := range orm.GetIter[UserInfo](ctx, db, "select * from userinfo") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func GetIter[T any](ctx context.Context, db DB, query string, args ...any) iter.Seq2[*T, error] {
+	conf, db := configOf(db)
+	args, opts := parseArgs(args...)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	query, args, err := prepareQuery(&conf, query, args)
+	if errors.Is(err, ErrSkipQuery) {
+		return func(yield func(*T, error) bool) {}
+	}
+	if err != nil {
+		return func(yield func(*T, error) bool) {
+			yield(nil, err)
+		}
+	}
+
+	return func(yield func(*T, error) bool) {
+		rows, err := queryContext(ctx, &conf, db, query, args...)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer rows.Close()
+
+		scanner, err := newRowScanner[T](ctx, &conf, rows)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for rows.Next() {
+			obj, err := scanner.scan(ctx, rows)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(obj, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			yield(nil, err)
+		}
 	}
+}
 
+// GetCount execute a query returning a single number, e.g. "SELECT COUNT(*) FROM userinfo".
+// 0 is returned when the query returns no row.
+// query and args may be rewritten. see [RewriteQuery] for detail
+func GetCount(ctx context.Context, db DB, query string, args ...any) (int64, error) {
+	count, err := GetOne[int64](ctx, db, query, args...)
+	if err != nil || count == nil {
+		return 0, err
+	}
+	return *count, nil
+}
+
+// Pluck execute a query returning a single column, and get the values of all rows,
+// e.g. Pluck[int64](ctx, db, "SELECT uid FROM userinfo").
+// query and args may be rewritten. see [RewriteQuery] for detail
+func Pluck[T any](ctx context.Context, db DB, query string, args ...any) ([]T, error) {
+	data := make([]T, 0)
+	for v, err := range GetIter[T](ctx, db, query, args...) {
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, *v)
+	}
 	return data, nil
 }
 
 func InsertOne(ctx context.Context, db DB, tableName string, data any, opts ...func(*config)) error {
-	conf := defaultConfig
+	conf, db := configOf(db)
 	for _, opt := range opts {
 		opt(&conf)
 	}
 
-	values := tagParser.Parse(conf.tagName, data)
+	if err := beforeInsert(ctx, data); err != nil {
+		return err
+	}
+
+	return insertOne(ctx, db, &conf, tableName, data, tagParser.Parse(conf.tagName, data))
+}
+
+// insertOne insert the values of data, and set the generated id back to it
+func insertOne(ctx context.Context, db DB, conf *config, tableName string, data any, values tag.Values[columnTag]) error {
 	insertColumns, autoIncrementColumn, args := parseInsertColumnsAndArgs(values)
-	query := generateInsertSQL(tableName, insertColumns, 1)
-	result, err := db.ExecContext(ctx, query, args...)
+	query := generateInsertSQL(conf.dialect, tableName, insertColumns, 1)
+
+	if autoIncrementColumn != "" && values.Get(autoIncrementColumn).CanSet() {
+		if returning := conf.dialect.Returning([]string{autoIncrementColumn}); returning != "" {
+			if err := insertReturning(ctx, db, conf, query+returning, args, values.Get(autoIncrementColumn)); err != nil {
+				return err
+			}
+			getSnapshot(data).take(values)
+			return afterInsert(ctx, data)
+		}
+	}
+
+	result, err := execGenerated(ctx, conf, db, query, args...)
 	if err != nil {
 		return err
 	}
@@ -117,60 +253,322 @@ func InsertOne(ctx context.Context, db DB, tableName string, data any, opts ...f
 		}
 		values.Get(autoIncrementColumn).Set(lastInsertId)
 	}
+	getSnapshot(data).take(values)
 
-	return nil
+	return afterInsert(ctx, data)
 }
 
+// InsertMany insert data in batches, see [SetBatchSize]. when T is a pointer type, the generated ids are set
+// back to the "autoincrement" field, as far as they can be determined. see [SetRequireInsertIDs]
 func InsertMany[T any](ctx context.Context, db DB, tableName string, data []T, opts ...func(*config)) error {
 	if len(data) == 0 {
 		return nil
 	}
 
-	conf := defaultConfig
+	conf, db := configOf(db)
 	for _, opt := range opts {
 		opt(&conf)
 	}
 
 	values := tagParser.Parse(conf.tagName, data[0])
-	insertColumns, _, _ := parseInsertColumnsAndArgs(values)
+	insertColumns, autoIncrementColumn, _ := parseInsertColumnsAndArgs(values)
+
+	return insertBatches(ctx, db, &conf, tableName, data, insertColumns, autoIncrementColumn, "", (*Snapshot).take)
+}
+
+// UpsertOne insert data, or update the existing row when the insert conflicts with it.
+//
+// the conflict target defaults to the "primary" tagged columns, see [ConflictColumns].
+// all other inserted columns are overwritten on conflict, see [UpdateColumns].
+// the "autoincrement" column is only inserted when it is part of the conflict target,
+// data is inserted as by [InsertOne] when it is zero, as it is a new row.
+//
+// when optimistic lock is enabled, the existing row is only updated when its version is
+// older than the version of data, so a stale upsert never overwrites newer data.
+// [ErrConcurrencyUpdate] is returned in that case
+func UpsertOne(ctx context.Context, db DB, tableName string, data any, opts ...func(*config)) error {
+	conf, db := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	if err := beforeInsert(ctx, data); err != nil {
+		return err
+	}
+
+	values := tagParser.Parse(conf.tagName, data)
+	columns, args, conflict, update, version := parseUpsertColumnsAndArgs(&conf, values)
+	if len(conflict) == 0 {
+		return ErrMissingPrimaryKey
+	}
+	if hasZeroAutoIncrement(values, conflict) {
+		// upserting the zero id would overwrite the row of that id, when any
+		return insertOne(ctx, db, &conf, tableName, data, values)
+	}
+	query := generateInsertSQL(conf.dialect, tableName, columns, 1) + conf.dialect.OnConflict(tableName, conflict, update, version)
+	result, err := execGenerated(ctx, &conf, db, query, args...)
+	if err != nil {
+		return err
+	}
+	if version != "" {
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrConcurrencyUpdate
+		}
+	}
+	// the row is either inserted, or updated with the conflict target matching already
+	getSnapshot(data).refresh(values, slices.Concat(conflict, update))
 
-	batchSize := min(conf.batchSize, len(data))
-	query := generateInsertSQL(tableName, insertColumns, batchSize)
-	args := make([]any, 0, len(insertColumns)*batchSize)
+	return afterInsert(ctx, data)
+}
 
-	for i := 0; i < len(data); i += batchSize {
-		args = args[:0]
-		end := min(i+batchSize, len(data))
-		batch := data[i:end]
+// UpsertMany is the batch version of [UpsertOne].
+// rows skipped because of a stale version are ignored silently.
+// rows are never inserted as by [InsertMany], insert new rows with a zero "autoincrement" key with it instead
+func UpsertMany[T any](ctx context.Context, db DB, tableName string, data []T, opts ...func(*config)) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	conf, db := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	values := tagParser.Parse(conf.tagName, data[0])
+	columns, _, conflict, update, version := parseUpsertColumnsAndArgs(&conf, values)
+	if len(conflict) == 0 {
+		return ErrMissingPrimaryKey
+	}
+	onConflict := conf.dialect.OnConflict(tableName, conflict, update, version)
+
+	written := slices.Concat(conflict, update)
+	snapshot := func(snap *Snapshot, values tag.Values[columnTag]) {
+		if version != "" {
+			// rows with a stale version are skipped, which ones is unknown
+			snap.reset()
+		} else {
+			snap.refresh(values, written)
+		}
+	}
+	return insertBatches(ctx, db, &conf, tableName, data, columns, "", onConflict, snapshot)
+}
+
+// insertBatches insert data in batches of at most batch size rows. suffix is appended to every statement.
+// when autoIncrement is not empty and elements of data are pointers, the generated ids are set back to them.
+// snapshot records what has been written into the snapshots of the rows
+func insertBatches[T any](ctx context.Context, db DB, conf *config, tableName string, data []T, insertColumns []string, autoIncrement, suffix string,
+	snapshot func(snap *Snapshot, values tag.Values[columnTag])) error {
+	for _, item := range data {
+		if err := beforeInsert(ctx, item); err != nil {
+			return err
+		}
+	}
+
+	if autoIncrement != "" && !tagParser.Parse(conf.tagName, data[0]).Get(autoIncrement).CanSet() {
+		autoIncrement = ""
+	}
+	returning := ""
+	if autoIncrement != "" {
+		returning = conf.dialect.Returning([]string{autoIncrement})
+	}
+
+	batchSize, err := batchRows(conf, len(data), len(insertColumns), func(rows int) string {
+		return generateInsertSQL(conf.dialect, tableName, insertColumns, rows) + suffix + returning
+	})
+	if err != nil {
+		return err
+	}
+	query := generateInsertSQL(conf.dialect, tableName, insertColumns, batchSize) + suffix + returning
+	if autoIncrement != "" && returning == "" && batchSize > 1 {
+		// checked before writing anything, the ids of multi-row batches couldn't be set back otherwise
+		if err = checkIncrement(ctx, conf, db); err != nil {
+			if conf.requireInsertIDs {
+				return err
+			}
+			autoIncrement = ""
+		}
+	}
+
+	err = runBatches(ctx, db, conf, len(data), batchSize, func(ctx context.Context, db DB, start, end int) error {
+		batch := data[start:end]
+		batchQuery := query
 		if len(batch) < batchSize {
-			query = generateInsertSQL(tableName, insertColumns, len(batch))
+			batchQuery = generateInsertSQL(conf.dialect, tableName, insertColumns, len(batch)) + suffix + returning
 		}
+		args := make([]any, 0, len(insertColumns)*len(batch))
 		for _, item := range batch {
 			itemValues := tagParser.Parse(conf.tagName, item)
 			for _, col := range insertColumns {
-				args = append(args, itemValues.Get(col).Interface())
+				args = append(args, columnArg(itemValues.Get(col)))
 			}
 		}
-		_, err := db.ExecContext(ctx, query, args...)
+
+		if returning != "" {
+			return insertBatchReturning(ctx, db, conf, batchQuery, args, batch, autoIncrement)
+		}
+		result, err := execGenerated(ctx, conf, db, batchQuery, args...)
 		if err != nil {
 			return err
 		}
+		if autoIncrement != "" {
+			if err := backfillInsertIDs(conf, result, batch, autoIncrement); err != nil && conf.requireInsertIDs {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range data {
+		snapshot(getSnapshot(item), tagParser.Parse(conf.tagName, item))
+		if err := afterInsert(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertBatchReturning execute a multi-row insert statement with "RETURNING" clause,
+// the returned ids are set to the rows of batch in order
+func insertBatchReturning[T any](ctx context.Context, db DB, conf *config, query string, args []any, batch []T, autoIncrement string) error {
+	rows, err := queryContext(ctx, conf, db, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		if n >= len(batch) {
+			break
+		}
+		err = rows.Scan(tagParser.Parse(conf.tagName, batch[n]).Get(autoIncrement).Addr())
+		if err != nil {
+			return err
+		}
+		n++
 	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if n != len(batch) {
+		return fmt.Errorf("%w: %d ids returned for %d rows", ErrInsertIDUnavailable, n, len(batch))
+	}
+	return nil
+}
 
+// backfillInsertIDs set the ids generated by a multi-row insert statement to the rows of batch.
+// it relies on the database generating consecutive ids for the rows of one statement
+func backfillInsertIDs[T any](conf *config, result sql.Result, batch []T, autoIncrement string) error {
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInsertIDUnavailable, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInsertIDUnavailable, err)
+	}
+	if rowsAffected != int64(len(batch)) {
+		return fmt.Errorf("%w: %d rows affected for %d rows", ErrInsertIDUnavailable, rowsAffected, len(batch))
+	}
+	firstId, ok := conf.dialect.FirstInsertID(lastInsertId, rowsAffected)
+	if !ok {
+		return fmt.Errorf("%w: not supported by %s", ErrInsertIDUnavailable, conf.dialect.Name())
+	}
+	for i, item := range batch {
+		tagParser.Parse(conf.tagName, item).Get(autoIncrement).Set(firstId + int64(i))
+	}
+	return nil
+}
+
+// increments are the steps between generated ids, by *sql.DB and query. see [Dialect.IncrementQuery]
+var increments sync.Map // incrementKey -> int64
+
+type incrementKey struct {
+	db    *sql.DB
+	query string
+}
+
+// checkIncrement returns [ErrInsertIDUnavailable] unless the database generates consecutive ids.
+// the step is queried once per *sql.DB, and on every call for a *sql.Tx not begun by [WithTx]
+func checkIncrement(ctx context.Context, conf *config, db DB) error {
+	query := conf.dialect.IncrementQuery()
+	if query == "" {
+		return nil
+	}
+
+	var key incrementKey
+	switch db := db.(type) {
+	case *sql.DB:
+		key = incrementKey{db: db, query: query}
+	case *sql.Tx:
+		if v, ok := txDBs.Load(db); ok {
+			key = incrementKey{db: v.(*sql.DB), query: query}
+		}
+	}
+	if key.db != nil {
+		if v, ok := increments.Load(key); ok {
+			return incrementError(v.(int64))
+		}
+	}
+
+	var increment int64
+	rows, err := queryContext(ctx, conf, db, query)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInsertIDUnavailable, err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return fmt.Errorf("%w: %q returned no row", ErrInsertIDUnavailable, query)
+	}
+	if err = rows.Scan(&increment); err != nil {
+		return fmt.Errorf("%w: %w", ErrInsertIDUnavailable, err)
+	}
+	if key.db != nil {
+		increments.Store(key, increment)
+	}
+	return incrementError(increment)
+}
+
+func incrementError(increment int64) error {
+	if increment != 1 {
+		return fmt.Errorf("%w: ids are generated with a step of %d", ErrInsertIDUnavailable, increment)
+	}
 	return nil
 }
 
+// UpdateOne update the row identified by the "primary" tagged columns of data.
+// the columns to update can be restricted with [Columns] and [Omit], or to the changed ones by embedding [Snapshot].
+// when there is nothing to update, no statement is executed and the [AfterUpdater] hook is not called.
+//
+// when optimistic lock is enabled, the "version" tagged column must match too, otherwise [ErrConcurrencyUpdate]
+// is returned. the version is bumped on success
 func UpdateOne(ctx context.Context, db DB, tableName string, data any, opts ...func(*config)) error {
-	conf := defaultConfig
+	conf, db := configOf(db)
 	for _, opt := range opts {
 		opt(&conf)
 	}
 
+	if err := beforeUpdate(ctx, data); err != nil {
+		return err
+	}
+
+	snap := getSnapshot(data)
 	values := tagParser.Parse(conf.tagName, data)
-	updateColumns, whereColumns, updateArgs, whereArgs, versionValue := parseUpdateColumnsAndArgs(&conf, values)
-	query := generateUpdateSQL(tableName, updateColumns, whereColumns)
+	updateColumns, whereColumns, updateArgs, whereArgs, versionValue := parseUpdateColumnsAndArgs(&conf, snap, values)
+	if len(updateColumns) == 0 || (versionValue != nil && len(updateColumns) == 1) {
+		// nothing to update
+		return nil
+	}
+	query := generateUpdateSQL(conf.dialect, tableName, updateColumns, whereColumns)
 	args := append(updateArgs, whereArgs...)
-	result, err := db.ExecContext(ctx, query, args...)
+	result, err := execGenerated(ctx, &conf, db, query, args...)
 	if err != nil {
 		return err
 	}
@@ -186,10 +584,103 @@ func UpdateOne(ctx context.Context, db DB, tableName string, data any, opts ...f
 			versionValue.Set(versionValue.Value().Int() + 1)
 		}
 	}
+	// only the written columns are saved, the others may still hold changes, see [Omit]
+	snap.refresh(values, updateColumns)
+
+	return afterUpdate(ctx, data)
+}
+
+// DeleteOne delete the row identified by the "primary" tagged columns of data.
+// when optimistic lock is enabled, the "version" tagged column must match too,
+// otherwise [ErrConcurrencyUpdate] is returned
+func DeleteOne(ctx context.Context, db DB, tableName string, data any, opts ...func(*config)) error {
+	conf, db := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	values := tagParser.Parse(conf.tagName, data)
+	whereColumns, args, versionValue := parseDeleteColumnsAndArgs(&conf, values)
+	if len(whereColumns) == 0 {
+		return ErrMissingPrimaryKey
+	}
+	query := generateDeleteSQL(conf.dialect, tableName, whereColumns)
+	result, err := execGenerated(ctx, &conf, db, query, args...)
+	if err != nil {
+		return err
+	}
+	if versionValue != nil {
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrConcurrencyUpdate
+		}
+	}
 
 	return nil
 }
 
+// DeleteMany delete the rows identified by the "primary" tagged columns of data.
+// keys are batched into "IN (...)" clauses of at most batch size keys
+func DeleteMany[T any](ctx context.Context, db DB, tableName string, data []T, opts ...func(*config)) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	conf, db := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	primaryColumns := parsePrimaryColumns(tagParser.Parse(conf.tagName, data[0]))
+	if len(primaryColumns) == 0 {
+		return ErrMissingPrimaryKey
+	}
+
+	batchSize, err := batchRows(&conf, len(data), len(primaryColumns), func(rows int) string {
+		return generateDeleteInSQL(conf.dialect, tableName, primaryColumns, rows, conf.rowValues)
+	})
+	if err != nil {
+		return err
+	}
+	query := generateDeleteInSQL(conf.dialect, tableName, primaryColumns, batchSize, conf.rowValues)
+
+	return runBatches(ctx, db, &conf, len(data), batchSize, func(ctx context.Context, db DB, start, end int) error {
+		batch := data[start:end]
+		batchQuery := query
+		if len(batch) < batchSize {
+			batchQuery = generateDeleteInSQL(conf.dialect, tableName, primaryColumns, len(batch), conf.rowValues)
+		}
+		args := make([]any, 0, len(primaryColumns)*len(batch))
+		for _, item := range batch {
+			itemValues := tagParser.Parse(conf.tagName, item)
+			for _, col := range primaryColumns {
+				args = append(args, itemValues.Get(col).Interface())
+			}
+		}
+		_, err := execGenerated(ctx, &conf, db, batchQuery, args...)
+		return err
+	})
+}
+
+// insertReturning execute an insert statement with "RETURNING" clause and scan the returned value into autoIncrement
+func insertReturning(ctx context.Context, db DB, conf *config, query string, args []any, autoIncrement tag.Value[columnTag]) error {
+	rows, err := queryContext(ctx, conf, db, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err = rows.Scan(autoIncrement.Addr()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func parseArgs(args ...any) (actualArgs []any, opts []func(*config)) {
 	for _, arg := range args {
 		opt, ok := arg.(func(*config))
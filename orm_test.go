@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -19,6 +20,10 @@ type UserInfo struct {
 	Version    int64      `orm:"version,version"`
 }
 
+func (UserInfo) TableName() string {
+	return "userinfo"
+}
+
 type CustomTagUserInfo struct {
 	Uid      int64      `foobar:"uid"`
 	Username string     `foobar:"username"`
@@ -111,6 +116,31 @@ func BenchmarkGetOne(b *testing.B) {
 	}
 }
 
+func BenchmarkGetMany(b *testing.B) {
+	db, _ := sql.Open("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1)
+	_, _ = db.Exec(`
+	CREATE TABLE userinfo (
+	 	uid INTEGER PRIMARY KEY AUTOINCREMENT,
+	 	username VARCHAR(64) NULL,
+	 	department VARCHAR(64) NULL,
+	 	created DATE NULL,
+		version INTEGER NOT NULL DEFAULT 0
+	 )`)
+	users := make([]*UserInfo, 1000)
+	for i := range users {
+		now := time.Now()
+		users[i] = &UserInfo{Username: fmt.Sprintf("user%d", i), Department: "dev", CreateAt: &now}
+	}
+	_ = InsertMany(context.Background(), db, "userinfo", users)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		us, _ := GetMany[UserInfo](context.Background(), db, "select * from userinfo")
+		_ = us
+	}
+}
+
 func Test_GetOne_Pointer(t *testing.T) {
 	db, err := sql.Open("sqlite3", "./foo.db")
 	assert.Nil(t, err)
@@ -141,10 +171,13 @@ func Test_GetOne_CustomTag_WithOpt(t *testing.T) {
 func Test_GetOne_Non_Struct(t *testing.T) {
 	db, err := sql.Open("sqlite3", "./foo.db")
 	assert.Nil(t, err)
-	result, err := GetOne[int](context.Background(), db, "select * from userinfo where uid =?", 1)
+	result, err := GetOne[int](context.Background(), db, "select uid from userinfo where uid =?", 1)
 	assert.Nil(t, err)
 	assert.NotNil(t, result)
-	assert.Equal(t, 0, *result)
+	assert.Equal(t, 1, *result)
+
+	_, err = GetOne[int](context.Background(), db, "select * from userinfo where uid =?", 1)
+	assert.NotNil(t, err)
 }
 
 func Test_GetMany(t *testing.T) {
@@ -240,3 +273,517 @@ func Test_UpdateOne(t *testing.T) {
 	err = UpdateOne(context.Background(), db, "userinfo", userinfo, EnableOptimisticLock(true))
 	assert.True(t, errors.Is(err, ErrConcurrencyUpdate))
 }
+
+func Test_InsertOne_SQLiteDialect(t *testing.T) {
+	db := initDb(t)
+	userinfo := &UserInfo{
+		Username:   "astaxie",
+		Department: "<UNK>",
+	}
+	err := InsertOne(context.Background(), db, "userinfo", userinfo, WithDialect(SQLite))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), userinfo.Uid)
+
+	userinfo.Username = "astaxie2"
+	err = UpdateOne(context.Background(), db, "userinfo", userinfo, WithDialect(SQLite))
+	assert.Nil(t, err)
+
+	u, err := GetOne[UserInfo](context.Background(), db, "select * from userinfo where uid in ?", []int64{1}, WithDialect(SQLite))
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie2", u.Username)
+}
+
+func Test_DeleteOne(t *testing.T) {
+	db := initDb(t)
+	userinfo := &UserInfo{
+		Username:   "astaxie",
+		Department: "<UNK>",
+	}
+	err := InsertOne(context.Background(), db, "userinfo", userinfo)
+	assert.Nil(t, err)
+
+	err = DeleteOne(context.Background(), db, "userinfo", CustomTagUserInfo{Uid: userinfo.Uid})
+	assert.True(t, errors.Is(err, ErrMissingPrimaryKey))
+
+	stale := *userinfo
+	stale.Version -= 1
+	err = DeleteOne(context.Background(), db, "userinfo", &stale, EnableOptimisticLock(true))
+	assert.True(t, errors.Is(err, ErrConcurrencyUpdate))
+
+	err = DeleteOne(context.Background(), db, "userinfo", userinfo, EnableOptimisticLock(true))
+	assert.Nil(t, err)
+
+	u, err := GetOne[UserInfo](context.Background(), db, "select * from userinfo where uid = ?", userinfo.Uid)
+	assert.Nil(t, err)
+	assert.Nil(t, u)
+}
+
+func Test_DeleteMany(t *testing.T) {
+	db := initDb(t)
+	userinfos := make([]*UserInfo, 5)
+	for i := range userinfos {
+		userinfos[i] = &UserInfo{Username: "astaxie"}
+		err := InsertOne(context.Background(), db, "userinfo", userinfos[i])
+		assert.Nil(t, err)
+	}
+
+	err := DeleteMany(context.Background(), db, "userinfo", userinfos[:3], WithBatchSize(2))
+	assert.Nil(t, err)
+
+	left, err := GetMany[UserInfo](context.Background(), db, "select * from userinfo")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(left))
+	assert.Equal(t, userinfos[3].Uid, left[0].Uid)
+	assert.Equal(t, userinfos[4].Uid, left[1].Uid)
+}
+
+func Test_UpsertOne(t *testing.T) {
+	db := initDb(t)
+	userinfo := &UserInfo{
+		Uid:      1,
+		Username: "astaxie",
+		Version:  1,
+	}
+	err := UpsertOne(context.Background(), db, "userinfo", userinfo, WithDialect(SQLite))
+	assert.Nil(t, err)
+
+	userinfo.Username = "astaxie2"
+	userinfo.Version = 2
+	err = UpsertOne(context.Background(), db, "userinfo", userinfo, WithDialect(SQLite), EnableOptimisticLock(true))
+	assert.Nil(t, err)
+
+	stale := &UserInfo{Uid: 1, Username: "stale", Version: 1}
+	err = UpsertOne(context.Background(), db, "userinfo", stale, WithDialect(SQLite), EnableOptimisticLock(true))
+	assert.True(t, errors.Is(err, ErrConcurrencyUpdate))
+
+	u, err := GetOne[UserInfo](context.Background(), db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie2", u.Username)
+	assert.Equal(t, int64(2), u.Version)
+
+	stale.Department = "dev"
+	err = UpsertOne(context.Background(), db, "userinfo", stale, WithDialect(SQLite), UpdateColumns("department"))
+	assert.Nil(t, err)
+	u, err = GetOne[UserInfo](context.Background(), db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie2", u.Username)
+	assert.Equal(t, "dev", u.Department)
+}
+
+func Test_UpsertOne_NewRows(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	a := &UserInfo{Username: "a"}
+	b := &UserInfo{Username: "b"}
+	assert.Nil(t, UpsertOne(ctx, db, "userinfo", a, WithDialect(SQLite)))
+	assert.Nil(t, UpsertOne(ctx, db, "userinfo", b, WithDialect(SQLite)))
+	assert.Equal(t, int64(1), a.Uid)
+	assert.Equal(t, int64(2), b.Uid)
+
+	names, err := Pluck[string](ctx, db, "select username from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	b.Username = "bb"
+	assert.Nil(t, UpsertOne(ctx, db, "userinfo", b, WithDialect(SQLite)))
+	names, err = Pluck[string](ctx, db, "select username from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "bb"}, names)
+}
+
+func Test_UpsertMany(t *testing.T) {
+	db := initDb(t)
+	err := InsertOne(context.Background(), db, "userinfo", &UserInfo{Username: "astaxie", Version: 1})
+	assert.Nil(t, err)
+
+	userinfos := []UserInfo{
+		{Uid: 1, Username: "astaxie2", Version: 2},
+		{Uid: 2, Username: "qqqq", Version: 1},
+		{Uid: 3, Username: "wwww", Version: 1},
+	}
+	err = UpsertMany(context.Background(), db, "userinfo", userinfos, WithDialect(SQLite), WithBatchSize(2), EnableOptimisticLock(true))
+	assert.Nil(t, err)
+
+	users, err := GetMany[UserInfo](context.Background(), db, "select * from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(users))
+	assert.Equal(t, "astaxie2", users[0].Username)
+	assert.Equal(t, int64(2), users[0].Version)
+	assert.Equal(t, "wwww", users[2].Username)
+}
+
+func Test_InsertMany_Backfill(t *testing.T) {
+	db := initDb(t)
+	err := InsertOne(context.Background(), db, "userinfo", &UserInfo{Username: "first"})
+	assert.Nil(t, err)
+
+	userinfos := make([]*UserInfo, 5)
+	for i := range userinfos {
+		userinfos[i] = &UserInfo{Username: fmt.Sprintf("user%d", i)}
+	}
+	err = InsertMany(context.Background(), db, "userinfo", userinfos, WithDialect(SQLite), WithBatchSize(2))
+	assert.Nil(t, err)
+
+	for i, userinfo := range userinfos {
+		assert.Equal(t, int64(i+2), userinfo.Uid)
+		u, err := GetOne[UserInfo](context.Background(), db, "select * from userinfo where uid = ?", userinfo.Uid)
+		assert.Nil(t, err)
+		assert.Equal(t, userinfo.Username, u.Username)
+	}
+
+	values := []UserInfo{{Username: "value"}}
+	err = InsertMany(context.Background(), db, "userinfo", values, WithDialect(SQLite))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), values[0].Uid)
+}
+
+func Test_InsertMany_MaxParams(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	var queries []string
+	record := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		queries = append(queries, info.Query)
+		return next(ctx)
+	})
+
+	userinfos := make([]*UserInfo, 5)
+	for i := range userinfos {
+		userinfos[i] = &UserInfo{Username: fmt.Sprintf("user%d", i)}
+	}
+	// 4 params per row
+	err := InsertMany(ctx, db, "userinfo", userinfos, WithDialect(SQLite), WithMaxParams(10), record)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(queries))
+	assert.Equal(t, int64(5), userinfos[4].Uid)
+	assert.Equal(t, 5, countUsers(t, db))
+
+	err = InsertMany(ctx, db, "userinfo", userinfos, WithMaxParams(3))
+	assert.True(t, errors.Is(err, ErrStatementTooLarge))
+
+	queries = nil
+	maxBytes := len(generateInsertSQL(MySQL, "userinfo", []string{"username", "department", "created", "version"}, 2))
+	err = InsertMany(ctx, db, "userinfo", userinfos[:3], WithMaxStatementBytes(maxBytes), record)
+	assert.Nil(t, err)
+	// the step query of mysql fails on sqlite first, the ids are left unset
+	assert.Equal(t, 3, len(queries))
+	assert.Equal(t, MySQL.IncrementQuery(), queries[0])
+	queries = queries[1:]
+	for _, query := range queries {
+		assert.LessOrEqual(t, len(query), maxBytes)
+	}
+
+	err = InsertMany(ctx, db, "userinfo", userinfos, WithMaxStatementBytes(10))
+	assert.True(t, errors.Is(err, ErrStatementTooLarge))
+}
+
+// steppedSQLite is a SQLite dialect generating ids like mysql with auto_increment_increment = 2
+type steppedSQLite struct {
+	Dialect
+}
+
+func (steppedSQLite) IncrementQuery() string {
+	return "SELECT 2"
+}
+
+func Test_InsertMany_Increment(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	userinfos := []*UserInfo{{Username: "a"}, {Username: "b"}}
+	err := InsertMany(ctx, db, "userinfo", userinfos, WithDialect(steppedSQLite{SQLite}), RequireInsertIDs(true))
+	assert.True(t, errors.Is(err, ErrInsertIDUnavailable))
+	// nothing is written
+	assert.Equal(t, 0, countUsers(t, db))
+
+	// written without ids by default
+	err = InsertMany(ctx, db, "userinfo", userinfos, WithDialect(steppedSQLite{SQLite}))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, countUsers(t, db))
+	assert.Equal(t, int64(0), userinfos[0].Uid)
+	assert.Equal(t, int64(0), userinfos[1].Uid)
+
+	// a single row gets its id from LastInsertId whatever the step
+	err = InsertMany(ctx, db, "userinfo", userinfos[:1], WithDialect(steppedSQLite{SQLite}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), userinfos[0].Uid)
+
+	// ids aren't set back to values, no need to check
+	err = InsertMany(ctx, db, "userinfo", []UserInfo{{Username: "c"}, {Username: "d"}}, WithDialect(steppedSQLite{SQLite}))
+	assert.Nil(t, err)
+}
+
+func Test_GetIter(t *testing.T) {
+	db := initDb(t)
+	for i := 0; i < 5; i++ {
+		err := InsertOne(context.Background(), db, "userinfo", &UserInfo{Username: fmt.Sprintf("user%d", i)})
+		assert.Nil(t, err)
+	}
+
+	var usernames []string
+	for user, err := range GetIter[UserInfo](context.Background(), db, "select * from userinfo where uid in ? order by uid", []int{1, 2, 3}) {
+		assert.Nil(t, err)
+		usernames = append(usernames, user.Username)
+	}
+	assert.Equal(t, []string{"user0", "user1", "user2"}, usernames)
+
+	count := 0
+	for _, err := range GetIter[UserInfo](context.Background(), db, "select * from userinfo") {
+		assert.Nil(t, err)
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 0, db.Stats().InUse)
+
+	for _, err := range GetIter[UserInfo](context.Background(), db, "select * from not_exist") {
+		assert.NotNil(t, err)
+	}
+}
+
+func Test_UpdateOne_Columns(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie", Department: "dev"})
+	assert.Nil(t, err)
+
+	err = UpdateOne(ctx, db, "userinfo", &UserInfo{Uid: 1, Username: "astaxie2"}, Columns("username"))
+	assert.Nil(t, err)
+	err = UpdateOne(ctx, db, "userinfo", &UserInfo{Uid: 1, Username: "ignored", Department: "ops"}, Omit("username", "created"))
+	assert.Nil(t, err)
+
+	u, err := GetOne[UserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie2", u.Username)
+	assert.Equal(t, "ops", u.Department)
+}
+
+type SnapshotUserInfo struct {
+	Snapshot
+	UserInfo
+}
+
+func Test_UpdateOne_Snapshot(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie", Department: "dev"})
+	assert.Nil(t, err)
+
+	var infos []*QueryInfo
+	record := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		infos = append(infos, info)
+		return next(ctx)
+	})
+
+	u1, err := GetOne[SnapshotUserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	u2, err := GetOne[SnapshotUserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+
+	err = UpdateOne(ctx, db, "userinfo", u1, record)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(infos))
+
+	u1.Username = "astaxie2"
+	err = UpdateOne(ctx, db, "userinfo", u1, record)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(infos))
+	assert.Equal(t, "UPDATE userinfo SET `username`=? WHERE `uid`=?", infos[0].Query)
+
+	err = UpdateOne(ctx, db, "userinfo", u1, record)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(infos))
+
+	u2.Department = "ops"
+	err = UpdateOne(ctx, db, "userinfo", u2, record, EnableOptimisticLock(true))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), u2.Version)
+
+	u, err := GetOne[UserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie2", u.Username)
+	assert.Equal(t, "ops", u.Department)
+	assert.Equal(t, int64(1), u.Version)
+}
+
+func Test_UpdateOne_Snapshot_Columns(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie", Department: "dev"})
+	assert.Nil(t, err)
+
+	for i, opt := range []func(*config){Columns("username"), Omit("department")} {
+		u1, err := GetOne[SnapshotUserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+		assert.Nil(t, err)
+		department := u1.Department
+		u1.Username = fmt.Sprintf("astaxie%d", i)
+		u1.Department = fmt.Sprintf("ops%d", i)
+		assert.Nil(t, UpdateOne(ctx, db, "userinfo", u1, opt))
+
+		u, err := GetOne[UserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, fmt.Sprintf("astaxie%d", i), u.Username)
+		assert.Equal(t, department, u.Department)
+
+		// the department is still changed, as it hasn't been written
+		assert.Nil(t, UpdateOne(ctx, db, "userinfo", u1))
+		u, err = GetOne[UserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, fmt.Sprintf("ops%d", i), u.Department)
+	}
+}
+
+func Test_UpsertMany_Snapshot(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie", Department: "dev"})
+	assert.Nil(t, err)
+
+	u, err := GetOne[SnapshotUserInfo](ctx, db, "select * from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	_, err = db.Exec("UPDATE userinfo SET version = 1 WHERE uid = 1")
+	assert.Nil(t, err)
+
+	// skipped because of the stale version, the snapshot mustn't record the department as saved
+	u.Department = "ops"
+	err = UpsertMany(ctx, db, "userinfo", []*SnapshotUserInfo{u}, WithDialect(SQLite), EnableOptimisticLock(true))
+	assert.Nil(t, err)
+	_, recorded := u.Snapshot.values["department"]
+	assert.False(t, recorded)
+}
+
+type NullZeroUserInfo struct {
+	Uid        int64  `orm:"uid,primary,autoincrement"`
+	Username   string `orm:"username"`
+	Department string `orm:"department,nullzero"`
+}
+
+func Test_NullAsZero(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	_, err := db.Exec("INSERT INTO userinfo(username, department) VALUES (NULL, NULL)")
+	assert.Nil(t, err)
+
+	_, err = GetOne[UserInfo](ctx, db, "select * from userinfo")
+	assert.NotNil(t, err)
+
+	u, err := GetOne[UserInfo](ctx, db, "select * from userinfo", NullAsZero(true))
+	assert.Nil(t, err)
+	assert.Equal(t, "", u.Username)
+	assert.Equal(t, "", u.Department)
+	assert.Nil(t, u.CreateAt)
+
+	_, err = db.Exec("UPDATE userinfo SET username = 'astaxie', department = 'dev'")
+	assert.Nil(t, err)
+	u, err = GetOne[UserInfo](ctx, db, "select * from userinfo", NullAsZero(true))
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie", u.Username)
+	assert.Equal(t, "dev", u.Department)
+
+	nz := &NullZeroUserInfo{Username: "qqqq"}
+	err = InsertOne(ctx, db, "userinfo", nz)
+	assert.Nil(t, err)
+	nulls, err := GetMany[NullZeroUserInfo](ctx, db, "select uid, department from userinfo where department is null")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(nulls))
+	assert.Equal(t, nz.Uid, nulls[0].Uid)
+	assert.Equal(t, "", nulls[0].Department)
+}
+
+func Test_ColumnMismatch(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie"})
+	assert.Nil(t, err)
+
+	_, err = GetOne[UserInfo](ctx, db, "select * from userinfo", Strict(true))
+	assert.Nil(t, err)
+
+	_, err = GetOne[UserInfo](ctx, db, "select uid, username, 1 as extra from userinfo", Strict(true))
+	var mismatch *ColumnMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, []string{"extra"}, mismatch.Unmapped)
+	assert.Equal(t, []string{"department", "created", "version"}, mismatch.Missing)
+
+	var reported []*ColumnMismatchError
+	report := OnColumnMismatch(func(ctx context.Context, mismatch *ColumnMismatchError) {
+		reported = append(reported, mismatch)
+	})
+	users, err := GetMany[UserInfo](ctx, db, "select *, 1 as extra from userinfo", report)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, 1, len(reported))
+	assert.Equal(t, []string{"extra"}, reported[0].Unmapped)
+	assert.Nil(t, reported[0].Missing)
+}
+
+type EmbeddedPointerUserInfo struct {
+	*UserInfo
+	Extra string `orm:"extra"`
+}
+
+func Test_GetMany_ScanPlan(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertMany(ctx, db, "userinfo", []*UserInfo{{Username: "astaxie", Department: "dev"}, {Username: "bob"}})
+	assert.Nil(t, err)
+
+	for range 2 {
+		users, err := GetMany[EmbeddedPointerUserInfo](ctx, db, "select uid, username, 'x' as extra from userinfo order by uid")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(users))
+		assert.Equal(t, int64(2), users[1].Uid)
+		assert.Equal(t, "bob", users[1].Username)
+		assert.Equal(t, "x", users[1].Extra)
+	}
+
+	// same type, different columns
+	users, err := GetMany[UserInfo](ctx, db, "select department, uid from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", users[0].Department)
+	assert.Equal(t, int64(1), users[0].Uid)
+	assert.Equal(t, "", users[0].Username)
+}
+
+func Test_GetOne_Scalar_Map_Tuple(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	err := InsertMany(ctx, db, "userinfo", []UserInfo{{Username: "a", CreateAt: &now}, {Username: "b"}})
+	assert.Nil(t, err)
+
+	count, err := GetCount(ctx, db, "select count(*) from userinfo")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	uids, err := Pluck[int64](ctx, db, "select uid from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2}, uids)
+
+	names, err := Pluck[*string](ctx, db, "select username from userinfo where uid in ? order by uid", []int{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "b", *names[1])
+
+	created, err := GetOne[time.Time](ctx, db, "select created from userinfo where uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, now, created.UTC())
+
+	createds, err := Pluck[time.Time](ctx, db, "select created from userinfo order by uid", NullAsZero(true))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(createds))
+	assert.True(t, createds[1].IsZero())
+
+	nullString, err := GetOne[sql.NullString](ctx, db, "select created from userinfo where uid = ?", 2)
+	assert.Nil(t, err)
+	assert.False(t, nullString.Valid)
+
+	row, err := GetOne[map[string]any](ctx, db, "select uid, username from userinfo where uid = ?", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{"uid": int64(2), "username": "b"}, *row)
+
+	tuples, err := GetMany[[]any](ctx, db, "select uid, username from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, []any{int64(1), "a"}, *tuples[0])
+}
@@ -0,0 +1,363 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/hyperchao/orm/tag"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// scanKind is how rows are scanned into a type
+type scanKind int
+
+const (
+	// scanModel scans columns into the tagged fields of a struct
+	scanModel scanKind = iota
+	// scanScalar scans a single column into a basic type, time.Time or a sql.Scanner
+	scanScalar
+	// scanMap scans a row into a map[string]any keyed by column
+	scanMap
+	// scanTuple scans a row into a []any in column order
+	scanTuple
+)
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+	mapRowType  = reflect.TypeFor[map[string]any]()
+	tupleType   = reflect.TypeFor[[]any]()
+)
+
+func scanKindOf(t reflect.Type) scanKind {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == mapRowType:
+		return scanMap
+	case t == tupleType:
+		return scanTuple
+	case t == timeType || reflect.PointerTo(t).Implements(scannerType):
+		return scanScalar
+	case t.Kind() == reflect.Struct:
+		return scanModel
+	default:
+		return scanScalar
+	}
+}
+
+// rowScanner scans rows of a result set into T.
+// the columns are resolved to fields of T once, and reused for every row
+type rowScanner[T any] struct {
+	conf     *config
+	kind     scanKind
+	columns  []string
+	nullZero bool // NULL is scanned as zero value, for scalars
+	plan     *scanPlan
+	dest     []any // reused between rows, rows.Scan doesn't retain it
+}
+
+func newRowScanner[T any](ctx context.Context, conf *config, rows *sql.Rows) (*rowScanner[T], error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	kind := scanKindOf(reflect.TypeFor[T]())
+	switch kind {
+	case scanScalar:
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("scan %s: expected 1 column, got %d", reflect.TypeFor[T](), len(columns))
+		}
+		t := reflect.TypeFor[T]()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		return &rowScanner[T]{
+			conf:     conf,
+			kind:     kind,
+			columns:  columns,
+			nullZero: conf.nullAsZero && !isNullable(t),
+		}, nil
+	case scanMap, scanTuple:
+		return &rowScanner[T]{
+			conf:    conf,
+			kind:    kind,
+			columns: columns,
+		}, nil
+	}
+
+	plan := getScanPlan[T](conf, columns)
+	if plan.mismatch != nil {
+		if conf.strict {
+			return nil, plan.mismatch
+		}
+		if conf.onColumnMismatch != nil {
+			conf.onColumnMismatch(ctx, plan.mismatch)
+		}
+	}
+
+	return &rowScanner[T]{
+		conf:    conf,
+		kind:    kind,
+		columns: columns,
+		plan:    plan,
+		dest:    make([]any, len(columns)),
+	}, nil
+}
+
+// scanPlan is how the columns of a result set are scanned into model T.
+// plans are cached by type and columns, so the tags of T are only looked up by the first query
+type scanPlan struct {
+	fields   []*scanField // by column, nil if the column isn't mapped to a field
+	mismatch *ColumnMismatchError
+}
+
+// scanField is the field a column is scanned into
+type scanField struct {
+	index []int
+	typ   reflect.Type
+	// direct is true when there is no pointer on the path to the field,
+	// which is then located at offset from the address of the model
+	direct     bool
+	offset     uintptr
+	nullZero   bool
+	serializer Serializer
+}
+
+type scanPlanKey struct {
+	typ        reflect.Type
+	tagName    string
+	nullAsZero bool
+	columns    string
+}
+
+var scanPlans sync.Map // scanPlanKey -> *scanPlan
+
+func getScanPlan[T any](conf *config, columns []string) *scanPlan {
+	key := scanPlanKey{
+		typ:        reflect.TypeFor[T](),
+		tagName:    conf.tagName,
+		nullAsZero: conf.nullAsZero,
+		columns:    strings.Join(columns, "\x00"),
+	}
+	if plan, ok := scanPlans.Load(key); ok {
+		return plan.(*scanPlan)
+	}
+
+	var obj T
+	values := tagParser.Parse(conf.tagName, &obj)
+	rt := reflect.TypeFor[T]()
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	mapped := make([]bool, len(columns))
+	fields := make([]*scanField, len(columns))
+	for i, col := range columns {
+		mapped[i] = values.Contains(col)
+		if !mapped[i] {
+			continue
+		}
+		meta := values.Get(col).Meta()
+		f := &scanField{
+			index:      meta.Index(),
+			typ:        meta.Type(),
+			serializer: meta.Attrs().serializer,
+		}
+		f.nullZero = f.serializer == nil && (conf.nullAsZero || meta.Attrs().Has(columnAttrNullZero)) && !isNullable(f.typ)
+		f.offset, f.direct = fieldOffset(rt, f.index)
+		fields[i] = f
+	}
+
+	plan := &scanPlan{fields: fields}
+	if values.Len() > 0 {
+		plan.mismatch = checkColumns[T](values, columns, mapped)
+	}
+	actual, _ := scanPlans.LoadOrStore(key, plan)
+	return actual.(*scanPlan)
+}
+
+// fieldOffset returns the offset of the field at index from the start of struct t.
+// ok is false when the path goes through a pointer
+func fieldOffset(t reflect.Type, index []int) (offset uintptr, ok bool) {
+	for i, idx := range index {
+		if i > 0 && t.Kind() != reflect.Struct {
+			return 0, false
+		}
+		field := t.Field(idx)
+		offset += field.Offset
+		t = field.Type
+	}
+	return offset, true
+}
+
+// checkColumns returns the mismatch between the result columns and the fields of T, nil if none
+func checkColumns[T any](values tag.Values[columnTag], columns []string, mapped []bool) *ColumnMismatchError {
+	var unmapped, missing []string
+	for i, col := range columns {
+		if !mapped[i] {
+			unmapped = append(unmapped, col)
+		}
+	}
+	for field := range values.Iter() {
+		if !slices.Contains(columns, field) {
+			missing = append(missing, field)
+		}
+	}
+	if len(unmapped) == 0 && len(missing) == 0 {
+		return nil
+	}
+	return &ColumnMismatchError{
+		Type:     reflect.TypeFor[T](),
+		Unmapped: unmapped,
+		Missing:  missing,
+	}
+}
+
+// isNullable reports whether NULL can be scanned into t as is
+func isNullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(scannerType)
+}
+
+// scan the current row into a new T
+func (s *rowScanner[T]) scan(ctx context.Context, rows *sql.Rows) (*T, error) {
+	var obj T
+	var err error
+	switch s.kind {
+	case scanScalar:
+		err = s.scanScalar(rows, &obj)
+	case scanMap, scanTuple:
+		err = s.scanValues(rows, &obj)
+	default:
+		err = s.scanModel(rows, &obj)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = afterScan(ctx, hookTarget(&obj)); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (s *rowScanner[T]) scanModel(rows *sql.Rows, obj *T) error {
+	if len(s.columns) == 0 {
+		return nil
+	}
+
+	root := indirectAlloc(reflect.ValueOf(obj).Elem())
+	base := root.Addr().UnsafePointer()
+	for i, f := range s.plan.fields {
+		switch {
+		case f == nil:
+			s.dest[i] = empty{}
+		case f.serializer != nil:
+			s.dest[i] = serializerDest{serializer: f.serializer, field: f.value(root, base)}
+		case f.nullZero:
+			s.dest[i] = nullZeroDest(f.typ)
+		case f.direct:
+			s.dest[i] = reflect.NewAt(f.typ, unsafe.Add(base, f.offset)).Interface()
+		default:
+			s.dest[i] = f.value(root, base).Addr().Interface()
+		}
+	}
+
+	if err := rows.Scan(s.dest...); err != nil {
+		return err
+	}
+	for i, f := range s.plan.fields {
+		if f != nil && f.nullZero {
+			setNullZero(f.value(root, base), s.dest[i])
+		}
+	}
+	if snap := getSnapshot(hookTarget(obj)); snap != nil {
+		snap.take(tagParser.Parse(s.conf.tagName, obj))
+	}
+	return nil
+}
+
+// value returns the field in root, whose address is base. nil pointers on the path are allocated
+func (f *scanField) value(root reflect.Value, base unsafe.Pointer) reflect.Value {
+	if f.direct {
+		return reflect.NewAt(f.typ, unsafe.Add(base, f.offset)).Elem()
+	}
+	v := root
+	for _, idx := range f.index {
+		v = indirectAlloc(v).Field(idx)
+	}
+	return v
+}
+
+func (s *rowScanner[T]) scanScalar(rows *sql.Rows, obj *T) error {
+	target := indirectAlloc(reflect.ValueOf(obj).Elem())
+	if !s.nullZero {
+		return rows.Scan(target.Addr().Interface())
+	}
+
+	dest := nullZeroDest(target.Type())
+	if err := rows.Scan(dest); err != nil {
+		return err
+	}
+	setNullZero(target, dest)
+	return nil
+}
+
+func (s *rowScanner[T]) scanValues(rows *sql.Rows, obj *T) error {
+	vals := make([]any, len(s.columns))
+	dest := make([]any, len(s.columns))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	target := indirectAlloc(reflect.ValueOf(obj).Elem())
+	if s.kind == scanTuple {
+		target.Set(reflect.ValueOf(vals))
+		return nil
+	}
+	m := make(map[string]any, len(s.columns))
+	for i, col := range s.columns {
+		m[col] = vals[i]
+	}
+	target.Set(reflect.ValueOf(m))
+	return nil
+}
+
+// nullZeroDest returns a pointer to a nil *t to scan into, NULL leaves it nil
+func nullZeroDest(t reflect.Type) any {
+	return reflect.New(reflect.PointerTo(t)).Interface()
+}
+
+// setNullZero set what has been scanned into dest to field, zero value if NULL
+func setNullZero(field reflect.Value, dest any) {
+	ptr := reflect.ValueOf(dest).Elem()
+	if ptr.IsNil() {
+		field.SetZero()
+	} else {
+		field.Set(ptr.Elem())
+	}
+}
+
+// indirectAlloc returns the value at the end of pointer indirection, allocating nil pointers
+func indirectAlloc(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,106 @@
+package orm
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	_ driver.Valuer = serialized{}
+)
+
+// Serializer converts field values to and from column values. a field is serialized by adding
+// the name the serializer is registered with to its tag, e.g. `orm:"settings,json"`.
+// "json" and "gob" are registered by default
+type Serializer interface {
+	// Marshal returns the column value of v, the value of the field
+	Marshal(v any) (driver.Value, error)
+	// Unmarshal decodes data read from the column into v, a pointer to the field
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	serializersMu sync.RWMutex
+	serializers   = map[string]Serializer{
+		"json": jsonSerializer{},
+		"gob":  gobSerializer{},
+	}
+)
+
+// RegisterSerializer register a serializer by name, replacing the one registered with the same name.
+// tags are parsed once per model type, so register serializers before using the models, e.g. in init
+func RegisterSerializer(name string, s Serializer) {
+	serializersMu.Lock()
+	defer serializersMu.Unlock()
+	serializers[name] = s
+}
+
+func getSerializer(name string) Serializer {
+	serializersMu.RLock()
+	defer serializersMu.RUnlock()
+	return serializers[name]
+}
+
+// jsonSerializer writes json as text
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v any) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (jsonSerializer) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobSerializer struct{}
+
+func (gobSerializer) Marshal(v any) (driver.Value, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobSerializer) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// serialized is the arg of a serializer column, marshalled when the statement is executed
+type serialized struct {
+	serializer Serializer
+	value      any
+}
+
+func (s serialized) Value() (driver.Value, error) {
+	return s.serializer.Marshal(s.value)
+}
+
+// serializerDest scans a serializer column into field, NULL is scanned as zero value
+type serializerDest struct {
+	serializer Serializer
+	field      reflect.Value
+}
+
+func (d serializerDest) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		d.field.SetZero()
+		return nil
+	case []byte:
+		return d.serializer.Unmarshal(src, d.field.Addr().Interface())
+	case string:
+		return d.serializer.Unmarshal([]byte(src), d.field.Addr().Interface())
+	default:
+		return fmt.Errorf("unsupported type %T of serializer column", src)
+	}
+}
@@ -0,0 +1,78 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type csvSerializer struct{}
+
+func (csvSerializer) Marshal(v any) (driver.Value, error) {
+	return strings.Join(v.([]string), ","), nil
+}
+
+func (csvSerializer) Unmarshal(data []byte, v any) error {
+	*v.(*[]string) = strings.Split(string(data), ",")
+	return nil
+}
+
+func init() {
+	RegisterSerializer("csv", csvSerializer{})
+}
+
+type Preference struct {
+	Theme    string `json:"theme"`
+	PageSize int    `json:"page_size"`
+}
+
+type UserSettings struct {
+	Uid        int64             `orm:"uid,primary"`
+	Preference *Preference       `orm:"preference,json"`
+	Labels     map[string]string `orm:"labels,json"`
+	Tags       []string          `orm:"tags,csv"`
+	Blob       Preference        `orm:"blob,gob"`
+}
+
+func Test_Serializer(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	_, err := db.Exec(`CREATE TABLE user_settings (uid INTEGER PRIMARY KEY, preference TEXT NULL, labels TEXT NULL, tags TEXT NULL, blob BLOB NULL)`)
+	assert.Nil(t, err)
+
+	settings := &UserSettings{
+		Uid:        1,
+		Preference: &Preference{Theme: "dark", PageSize: 20},
+		Labels:     map[string]string{"team": "infra"},
+		Tags:       []string{"a", "b"},
+		Blob:       Preference{Theme: "light"},
+	}
+	err = InsertOne(ctx, db, "user_settings", settings)
+	assert.Nil(t, err)
+
+	var raw string
+	err = db.QueryRow("SELECT preference FROM user_settings").Scan(&raw)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"theme":"dark","page_size":20}`, raw)
+
+	s, err := GetByPK[UserSettings](ctx, db, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, settings, s)
+
+	s.Tags = append(s.Tags, "c")
+	s.Preference.PageSize = 50
+	err = UpdateOne(ctx, db, "user_settings", s)
+	assert.Nil(t, err)
+	s, err = GetByPK[UserSettings](ctx, db, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, s.Tags)
+	assert.Equal(t, 50, s.Preference.PageSize)
+
+	_, err = db.Exec("UPDATE user_settings SET preference = NULL")
+	assert.Nil(t, err)
+	s, err = GetByPK[UserSettings](ctx, db, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Preference)
+}
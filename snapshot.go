@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"github.com/hyperchao/orm/tag"
+	"reflect"
+)
+
+// Snapshot records the column values of a model as they were last loaded from or written to the database.
+// embed it into a model to enable dirty tracking: [UpdateOne] then only updates the changed columns,
+// and skips the statement entirely when nothing changed.
+//
+//	type UserInfo struct {
+//		orm.Snapshot
+//		Uid      int64  `orm:"uid,primary,autoincrement"`
+//		Username string `orm:"username"`
+//	}
+//
+// snapshots are taken by [GetOne], [GetMany], [GetIter], [InsertOne] and [InsertMany]. [UpdateOne], [UpsertOne]
+// and [UpsertMany] only record the columns they write, see [Columns] and [Omit]. a model without snapshot, e.g. a new one, updates every column
+type Snapshot struct {
+	values map[string]any
+}
+
+type snapshotter interface {
+	snapshot() *Snapshot
+}
+
+func (s *Snapshot) snapshot() *Snapshot {
+	return s
+}
+
+// getSnapshot returns the snapshot embedded in data, nil if none
+func getSnapshot(data any) *Snapshot {
+	if s, ok := data.(snapshotter); ok {
+		return s.snapshot()
+	}
+	return nil
+}
+
+// take records the current values
+func (s *Snapshot) take(values tag.Values[columnTag]) {
+	if s == nil {
+		return
+	}
+	s.values = make(map[string]any, values.Len())
+	for field, value := range values.Iter() {
+		s.values[field] = snapshotValue(value.Value())
+	}
+}
+
+// refresh records the current values of columns, the values of the other columns are kept.
+// e.g. after an update restricted by [Columns], the other columns still hold unsaved changes
+func (s *Snapshot) refresh(values tag.Values[columnTag], columns []string) {
+	if s == nil {
+		return
+	}
+	if s.values == nil {
+		s.values = make(map[string]any, len(columns))
+	}
+	for _, col := range columns {
+		if values.Contains(col) {
+			s.values[col] = snapshotValue(values.Get(col).Value())
+		}
+	}
+}
+
+// reset forgets the recorded values, so every column is changed. it is used when it is unknown
+// what has been written, e.g. rows of an upsert may be skipped because of a stale version
+func (s *Snapshot) reset() {
+	if s == nil {
+		return
+	}
+	s.values = nil
+}
+
+// changed reports whether the value differs from the snapshot. everything is changed without snapshot
+func (s *Snapshot) changed(field string, value tag.Value[columnTag]) bool {
+	if s == nil || s.values == nil {
+		return true
+	}
+	old, ok := s.values[field]
+	if !ok {
+		return true
+	}
+	return !reflect.DeepEqual(old, snapshotValue(value.Value()))
+}
+
+// snapshotValue copies v, so later changes to v made through pointers, slices or maps are not reflected
+func snapshotValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return snapshotValue(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c.Interface()
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return c.Interface()
+	default:
+		return v.Interface()
+	}
+}
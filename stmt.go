@@ -0,0 +1,170 @@
+package orm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// stmtCache is a LRU cache of the statements prepared for generated SQL, keyed by *sql.DB and SQL text.
+// statements in use are closed only when released, after they are evicted
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[stmtKey]*list.Element
+	lru     list.List // front is the most recently used
+}
+
+type stmtKey struct {
+	db    *sql.DB
+	query string
+}
+
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		entries: make(map[stmtKey]*list.Element),
+	}
+}
+
+// txDBs are the *sql.DB of the transactions begun by [WithTx], to reuse the statements prepared on them
+var txDBs sync.Map // *sql.Tx -> *sql.DB
+
+// execGenerated run a generated statement, e.g. INSERT and UPDATE, through the statement cache of conf when enabled
+func execGenerated(ctx context.Context, conf *config, db DB, query string, args ...any) (sql.Result, error) {
+	if conf.stmtCache {
+		db = stmtDB{DB: db, stmts: conf.stmts}
+	}
+	return execContext(ctx, conf, db, query, args...)
+}
+
+// stmtDB executes statements of db through stmts.
+// only *sql.DB, and *sql.Tx begun by [WithTx], are supported, others execute statements directly
+type stmtDB struct {
+	DB
+	stmts *stmtCache
+}
+
+func (s stmtDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var tx *sql.Tx
+	db, ok := s.DB.(*sql.DB)
+	if !ok {
+		if tx, ok = s.DB.(*sql.Tx); ok {
+			if v, exists := txDBs.Load(tx); exists {
+				db = v.(*sql.DB)
+			}
+		}
+	}
+	if db == nil {
+		return s.DB.ExecContext(ctx, query, args...)
+	}
+
+	entry, err := s.stmts.acquire(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer s.stmts.release(entry)
+
+	if tx == nil {
+		return entry.stmt.ExecContext(ctx, args...)
+	}
+	stmt := tx.StmtContext(ctx, entry.stmt)
+	defer stmt.Close()
+	return stmt.ExecContext(ctx, args...)
+}
+
+// acquire returns the statement of query prepared on db, preparing it if not cached.
+// it must be released after use
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, query string) (*stmtEntry, error) {
+	key := stmtKey{db: db, query: query}
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	// prepare without holding the lock, the statement may be prepared concurrently
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		_ = stmt.Close()
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		return entry, nil
+	}
+	entry := &stmtEntry{key: key, stmt: stmt, refs: 1}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.evict()
+	return entry, nil
+}
+
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// evict the least recently used statements beyond the size of the cache, must hold c.mu
+func (c *stmtCache) evict() {
+	for c.lru.Len() > max(c.size, 0) {
+		entry := c.lru.Remove(c.lru.Back()).(*stmtEntry)
+		delete(c.entries, entry.key)
+		entry.evicted = true
+		if entry.refs == 0 {
+			_ = entry.stmt.Close()
+		}
+	}
+}
+
+// purge close the statements prepared on db, those in use when they are released
+func (c *stmtCache) purge(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if key.db != db {
+			continue
+		}
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		entry := elem.Value.(*stmtEntry)
+		entry.evicted = true
+		if entry.refs == 0 {
+			_ = entry.stmt.Close()
+		}
+	}
+}
+
+func (c *stmtCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	c.evict()
+}
+
+// len returns the number of cached statements
+func (c *stmtCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,99 @@
+package orm
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_StmtCache(t *testing.T) {
+	db := initDb(t)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	before := defaultConfig.Load().stmts.len()
+
+	users := []*UserInfo{{Username: "a"}, {Username: "b"}, {Username: "c"}, {Username: "d"}, {Username: "e"}}
+	err := InsertMany(ctx, db, "userinfo", users, WithBatchSize(2), WithDialect(SQLite), EnableStmtCache(true))
+	assert.Nil(t, err)
+	// batches of 2 rows share a statement, the last batch of 1 row has its own
+	assert.Equal(t, before+2, defaultConfig.Load().stmts.len())
+	assert.Equal(t, int64(5), users[4].Uid)
+
+	users[0].Username = "aa"
+	assert.Nil(t, UpdateOne(ctx, db, "userinfo", users[0], EnableStmtCache(true)))
+	users[1].Username = "bb"
+	assert.Nil(t, UpdateOne(ctx, db, "userinfo", users[1], EnableStmtCache(true)))
+	assert.Equal(t, before+3, defaultConfig.Load().stmts.len())
+
+	err = WithTx(ctx, db, func(tx DB) error {
+		users[2].Username = "cc"
+		return UpdateOne(ctx, tx, "userinfo", users[2], EnableStmtCache(true))
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, before+3, defaultConfig.Load().stmts.len())
+
+	names, err := Pluck[string](ctx, db, "select username from userinfo order by uid")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"aa", "bb", "cc", "d", "e"}, names)
+
+	// disabled by default
+	users[3].Username = "dd"
+	assert.Nil(t, DeleteOne(ctx, db, "userinfo", users[3]))
+	assert.Equal(t, before+3, defaultConfig.Load().stmts.len())
+}
+
+func Test_StmtCache_Evict(t *testing.T) {
+	db := initDb(t)
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	defer SetStmtCacheSize(128)
+
+	SetStmtCacheSize(1)
+	assert.Equal(t, 1, defaultConfig.Load().stmts.len())
+	for i := range 3 {
+		users := make([]*UserInfo, i+1)
+		for j := range users {
+			users[j] = &UserInfo{Username: "evict"}
+		}
+		assert.Nil(t, InsertMany(ctx, db, "userinfo", users, EnableStmtCache(true)))
+		assert.Equal(t, 1, defaultConfig.Load().stmts.len())
+	}
+	assert.Equal(t, 6, countUsers(t, db))
+
+	stmts := defaultConfig.Load().stmts
+	entry, err := stmts.acquire(ctx, db, "select 1")
+	assert.Nil(t, err)
+	SetStmtCacheSize(0)
+	// still usable until released
+	_, err = entry.stmt.ExecContext(ctx)
+	assert.Nil(t, err)
+	stmts.release(entry)
+	_, err = entry.stmt.ExecContext(ctx)
+	assert.NotNil(t, err)
+}
+
+func Test_PurgeStmtCache(t *testing.T) {
+	db := initDb(t)
+	other := initDb(t)
+	ctx := context.Background()
+	stmts := defaultConfig.Load().stmts
+	before := stmts.len()
+
+	assert.Nil(t, InsertOne(ctx, db, "userinfo", &UserInfo{Username: "a"}, EnableStmtCache(true)))
+	assert.Nil(t, InsertOne(ctx, other, "userinfo", &UserInfo{Username: "a"}, EnableStmtCache(true)))
+	assert.Equal(t, before+2, stmts.len())
+
+	entry, err := stmts.acquire(ctx, db, "select 1")
+	assert.Nil(t, err)
+	PurgeStmtCache(db)
+	assert.Equal(t, before+1, stmts.len())
+	// still usable until released
+	_, err = entry.stmt.ExecContext(ctx)
+	assert.Nil(t, err)
+	stmts.release(entry)
+	_, err = entry.stmt.ExecContext(ctx)
+	assert.NotNil(t, err)
+
+	PurgeStmtCache(other)
+	assert.Equal(t, before, stmts.len())
+}
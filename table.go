@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Tabler is implemented by models providing their table name.
+// models without it use the snake case of their type name, e.g. "user_info" for UserInfo
+type Tabler interface {
+	TableName() string
+}
+
+// tableNameOf returns the table name of model T
+func tableNameOf[T any]() string {
+	rt := reflect.TypeFor[T]()
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if t, ok := reflect.New(rt).Interface().(Tabler); ok {
+		return t.TableName()
+	}
+	return snakeCase(rt.Name())
+}
+
+// snakeCase convert a Go identifier to snake case, e.g. "HTTPServer" to "http_server"
+func snakeCase(name string) string {
+	runes := []rune(name)
+	sb := strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// modelColumns returns the columns of model T in declaration order
+func modelColumns[T any](conf *config) []string {
+	var obj T
+	values := tagParser.Parse(conf.tagName, &obj)
+	columns := make([]string, 0, values.Len())
+	for field := range values.Iter() {
+		columns = append(columns, field)
+	}
+	return columns
+}
+
+// GetByPK get the row of T's table whose "primary" tagged columns equal pk, nil if none.
+// pk are given in the declaration order of the primary key fields.
+// options can be passed along with pk, like [GetOne]
+func GetByPK[T any](ctx context.Context, db DB, pk ...any) (*T, error) {
+	conf, _ := configOf(db)
+	pk, opts := parseArgs(pk...)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	var obj T
+	primaryColumns := parsePrimaryColumns(tagParser.Parse(conf.tagName, &obj))
+	if len(primaryColumns) == 0 {
+		return nil, ErrMissingPrimaryKey
+	}
+	if len(pk) != len(primaryColumns) {
+		return nil, fmt.Errorf("%w: %d values given for %d primary key columns", ErrMissingPrimaryKey, len(pk), len(primaryColumns))
+	}
+
+	w := newSQLWriter(conf.dialect)
+	writeSelectSQL(w, tableNameOf[T](), modelColumns[T](&conf))
+	writeWhereSQL(w, primaryColumns)
+
+	args := make([]any, 0, len(pk)+len(opts)+1)
+	args = append(args, pk...)
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	// placeholders are already in the syntax of the dialect
+	args = append(args, EnableRewriteQuery(false))
+	return GetOne[T](ctx, db, w.String(), args...)
+}
+
+// Find get the rows of T's table matching where, all rows when where is empty.
+// the columns of T are selected explicitly.
+// query and args may be rewritten. see [RewriteQuery] for detail
+//
+//	users, err := orm.Find[UserInfo](ctx, db, "department = ? AND uid IN ?", "dev", uids)
+func Find[T any](ctx context.Context, db DB, where string, args ...any) ([]*T, error) {
+	conf, _ := configOf(db)
+	_, opts := parseArgs(args...)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	w := newSQLWriter(conf.dialect)
+	writeSelectSQL(w, tableNameOf[T](), modelColumns[T](&conf))
+	if where != "" {
+		w.WriteString(" WHERE ")
+		w.WriteString(where)
+	}
+	return GetMany[T](ctx, db, w.String(), args...)
+}
+
+// writeSelectSQL write "SELECT columns FROM table", "*" is selected when there is no column
+func writeSelectSQL(w *sqlWriter, tableName string, columns []string) {
+	w.WriteString("SELECT ")
+	if len(columns) == 0 {
+		w.WriteString("*")
+	}
+	for i, col := range columns {
+		if i > 0 {
+			w.WriteString(separator)
+		}
+		w.writeIdentifier(col)
+	}
+	w.WriteString(" FROM ")
+	w.WriteString(tableName)
+}
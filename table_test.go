@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type UserRole struct {
+	TenantId int64  `orm:"tenant_id,primary"`
+	Uid      int64  `orm:"uid,primary"`
+	Role     string `orm:"role"`
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "userinfo", tableNameOf[UserInfo]())
+	assert.Equal(t, "userinfo", tableNameOf[*UserInfo]())
+	assert.Equal(t, "user_role", tableNameOf[UserRole]())
+	assert.Equal(t, "http_server", snakeCase("HTTPServer"))
+	assert.Equal(t, "user_id2", snakeCase("UserId2"))
+}
+
+func Test_GetByPK(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	_, err := db.Exec(`CREATE TABLE user_role (tenant_id INTEGER, uid INTEGER, role VARCHAR(64), PRIMARY KEY (tenant_id, uid))`)
+	assert.Nil(t, err)
+
+	err = InsertOne(ctx, db, "userinfo", &UserInfo{Username: "astaxie"})
+	assert.Nil(t, err)
+	err = InsertMany(ctx, db, "user_role", []UserRole{{1, 1, "admin"}, {2, 1, "guest"}})
+	assert.Nil(t, err)
+
+	var infos []*QueryInfo
+	record := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		infos = append(infos, info)
+		return next(ctx)
+	})
+
+	u, err := GetByPK[UserInfo](ctx, db, 1, record)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie", u.Username)
+	assert.Equal(t, "SELECT `uid`,`username`,`department`,`created`,`version` FROM userinfo WHERE `uid`=?", infos[0].Query)
+
+	u, err = GetByPK[UserInfo](ctx, db, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, u)
+
+	role, err := GetByPK[UserRole](ctx, db, 2, 1, WithDialect(SQLite))
+	assert.Nil(t, err)
+	assert.Equal(t, "guest", role.Role)
+
+	_, err = GetByPK[UserRole](ctx, db, 2)
+	assert.True(t, errors.Is(err, ErrMissingPrimaryKey))
+}
+
+func Test_Find(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	err := InsertMany(ctx, db, "userinfo", []UserInfo{{Username: "a", Department: "dev"}, {Username: "b", Department: "dev"}, {Username: "c"}})
+	assert.Nil(t, err)
+
+	users, err := Find[UserInfo](ctx, db, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(users))
+
+	users, err = Find[UserInfo](ctx, db, "department = ? AND username IN ?", "dev", []string{"b", "c"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "b", users[0].Username)
+}
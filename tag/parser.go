@@ -20,20 +20,33 @@ func NewParser[T any](f ParseFunc[T]) *Parser[T] {
 	}
 }
 
+type cacheKey struct {
+	tagName string
+	typ     reflect.Type
+}
+
+// fields are the tagged fields of a type
+type fields[T any] struct {
+	metas map[string]*meta[T]
+	names []string // in declaration order
+}
+
 func (p *Parser[T]) Parse(tagName string, val any) Values[T] {
-	var metas map[string]*meta[T]
+	var f *fields[T]
 
 	rt := indirectT(reflect.TypeOf(val))
-	ret, ok := p.cache.Load(rt)
+	key := cacheKey{tagName: tagName, typ: rt}
+	ret, ok := p.cache.Load(key)
 	if ok {
-		metas = ret.(map[string]*meta[T])
+		f = ret.(*fields[T])
 	} else {
-		metas = make(map[string]*meta[T])
-		p.traverse(tagName, rt, nil, metas, make(map[reflect.Type]struct{}))
-		p.cache.Store(rt, metas)
+		f = &fields[T]{metas: make(map[string]*meta[T])}
+		p.traverse(tagName, rt, nil, f, make(map[reflect.Type]struct{}))
+		p.cache.Store(key, f)
 	}
 	return &values[T]{
-		metas: metas,
+		metas: f.metas,
+		names: f.names,
 		value: reflect.ValueOf(val),
 	}
 }
@@ -42,7 +55,7 @@ func (p *Parser[T]) traverse(
 	tagName string,
 	rt reflect.Type,
 	path []int,
-	metas map[string]*meta[T],
+	f *fields[T],
 	visitedTypes map[reflect.Type]struct{}) {
 
 	if rt.Kind() != reflect.Struct {
@@ -67,14 +80,17 @@ func (p *Parser[T]) traverse(
 			indices := make([]int, len(path), len(path)+1)
 			copy(indices, path)
 			indices = append(indices, i)
-			metas[name] = &meta[T]{
+			if _, exists := f.metas[name]; !exists {
+				f.names = append(f.names, name)
+			}
+			f.metas[name] = &meta[T]{
 				name:    name,
 				attrs:   attrs,
 				indices: indices,
 				typ:     field.Type,
 			}
 		} else if isStructOrIndirectToStruct(field.Type) {
-			p.traverse(tagName, indirectT(field.Type), append(path, i), metas, visitedTypes)
+			p.traverse(tagName, indirectT(field.Type), append(path, i), f, visitedTypes)
 		}
 	}
 }
@@ -63,3 +63,24 @@ func TestParser_Parse_Set_Interface(t *testing.T) {
 	innerValues.Get("name").Set("yyy")
 	assert.Equal(t, "yyy", modelValues.Get("name").Interface())
 }
+
+func TestParser_Parse_Order_TagName(t *testing.T) {
+	type Model struct {
+		C string `test:"c" other:"x"`
+		B string `test:"b"`
+		A string `test:"a" other:"y"`
+	}
+
+	parser := NewParser(parseFunc)
+	var names []string
+	for name := range parser.Parse("test", &Model{}).Iter() {
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"c", "b", "a"}, names)
+
+	names = names[:0]
+	for name := range parser.Parse("other", &Model{}).Iter() {
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"x", "y"}, names)
+}
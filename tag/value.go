@@ -22,6 +22,8 @@ type Meta[T any] interface {
 	Name() string
 	Attrs() T
 	Type() reflect.Type
+	// Index is the index sequence of the field, see [reflect.Value.FieldByIndex]
+	Index() []int
 }
 
 func (m *meta[T]) Name() string {
@@ -36,6 +38,10 @@ func (m *meta[T]) Type() reflect.Type {
 	return m.typ
 }
 
+func (m *meta[T]) Index() []int {
+	return m.indices
+}
+
 type Value[T any] interface {
 	Meta() Meta[T]
 	Interface() any
@@ -48,12 +54,14 @@ type Value[T any] interface {
 type Values[T any] interface {
 	Contains(name string) bool
 	Get(name string) Value[T]
+	// Iter iterates the values in the declaration order of the fields
 	Iter() iter.Seq2[string, Value[T]]
 	Len() int
 }
 
 type values[T any] struct {
 	metas map[string]*meta[T]
+	names []string
 	value reflect.Value
 }
 
@@ -77,7 +85,7 @@ func (v *values[T]) Get(field string) Value[T] {
 
 func (v *values[T]) Iter() iter.Seq2[string, Value[T]] {
 	return func(yield func(string, Value[T]) bool) {
-		for name := range v.metas {
+		for _, name := range v.names {
 			value := v.Get(name)
 			if !yield(name, value) {
 				return
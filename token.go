@@ -0,0 +1,201 @@
+package orm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// skipLiteral returns the end of the string literal, quoted identifier, comment or dollar-quoted string
+// starting at i of query, i if there is none
+func skipLiteral(d Dialect, query string, i int) int {
+	switch c := query[i]; c {
+	case '\'', '"', '`':
+		// quotes are escaped by doubling them, or with a backslash when the dialect allows it
+		// and in Postgres escape strings, e.g. E'it\'s'
+		backslash := c != '`' && (d.BackslashEscapes() || c == '\'' && isEscapeString(query, i))
+		for j := i + 1; j < len(query); j++ {
+			switch query[j] {
+			case '\\':
+				if backslash {
+					j++
+				}
+			case c:
+				if j+1 < len(query) && query[j+1] == c {
+					j++
+					continue
+				}
+				return j + 1
+			}
+		}
+		return len(query)
+	case '#':
+		if d.HashComments() {
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				return i + end + 1
+			}
+			return len(query)
+		}
+	case '-':
+		if strings.HasPrefix(query[i:], "--") {
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				return i + end + 1
+			}
+			return len(query)
+		}
+	case '/':
+		if strings.HasPrefix(query[i:], "/*") {
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				return i + 2 + end + 2
+			}
+			return len(query)
+		}
+	case '$':
+		// $$...$$ or $tag$...$tag$, but not $1 placeholders
+		if i > 0 && isNamePart(query[i-1]) {
+			return i
+		}
+		j := i + 1
+		for j < len(query) && isNamePart(query[j]) {
+			if j == i+1 && !isNameStart(query[j]) {
+				return i
+			}
+			j++
+		}
+		if j >= len(query) || query[j] != '$' {
+			return i
+		}
+		delimiter := query[i : j+1]
+		if end := strings.Index(query[j+1:], delimiter); end >= 0 {
+			return j + 1 + end + len(delimiter)
+		}
+		return len(query)
+	}
+	return i
+}
+
+// isEscapeString reports whether the quote at i of query starts a Postgres escape string, e.g. E'it\'s',
+// the "E" not ending a keyword or an identifier, e.g. "LIKE'a\'"
+func isEscapeString(query string, i int) bool {
+	return i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i == 1 || !isNamePart(query[i-2]))
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
+
+// rewriteEmptyIn replace the "x IN" or "x NOT IN" predicate at the end of w with "1=0" or "1=1"
+func rewriteEmptyIn(w *sqlWriter) error {
+	s := w.String()
+	start, _, not, err := inPredicate(s)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEmptyInClause, err)
+	}
+	if start < w.placeholderEnd {
+		// the args of the placeholders removed with x would be left over
+		return fmt.Errorf("%w: the operand of IN has placeholders", ErrEmptyInClause)
+	}
+	w.Reset()
+	w.WriteString(s[:start])
+	if start > 0 && isNamePart(s[start-1]) {
+		// e.g. "AND(a, b) IN"
+		w.WriteByte(' ')
+	}
+	if not {
+		w.WriteString("1=1")
+	} else {
+		w.WriteString("1=0")
+	}
+	return nil
+}
+
+// inPredicate parse the "x IN" or "x NOT IN" predicate s ends with, start is where x starts
+func inPredicate(s string) (start int, operand string, not bool, err error) {
+	end := len(strings.TrimRight(s, " \t\r\n"))
+	if end < 2 || !strings.EqualFold(s[end-2:end], "IN") || (end > 2 && isNamePart(s[end-3])) {
+		return 0, "", false, fmt.Errorf("the placeholder doesn't follow IN")
+	}
+	end = len(strings.TrimRight(s[:end-2], " \t\r\n"))
+	if end >= 3 && strings.EqualFold(s[end-3:end], "NOT") && (end == 3 || !isNamePart(s[end-4])) {
+		end = len(strings.TrimRight(s[:end-3], " \t\r\n"))
+		not = true
+	}
+
+	start = operandStart(s, end)
+	if start < 0 {
+		return 0, "", false, fmt.Errorf("no operand before IN")
+	}
+	return start, s[start:end], not, nil
+}
+
+// operandKeywords are the keywords which may directly precede a parenthesized operand, e.g. "AND(a, b) IN ?"
+var operandKeywords = map[string]bool{"and": true, "or": true, "not": true, "where": true, "on": true, "having": true, "when": true}
+
+// matchingParen returns the index of the "(" matching the ")" at end of s, -1 if none
+func matchingParen(s string, end int) int {
+	depth := 0
+	for i := end; i >= 0; i-- {
+		if s[i] == ')' {
+			depth++
+		} else if s[i] == '(' {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// operandStart returns the start of the operand ending at end of s, e.g. "t.uid", `"uid"`, "lower(name)" or "(a, b)".
+// -1 if there is none
+func operandStart(s string, end int) int {
+	start := end
+	for {
+		switch {
+		case start == 0:
+		case s[start-1] == ')':
+			open := matchingParen(s, start-1)
+			if open < 0 {
+				return -1
+			}
+			start = open
+			// function call, e.g. "lower(name)"
+			name := start
+			for name > 0 && isNamePart(s[name-1]) {
+				name--
+			}
+			if name == start || operandKeywords[strings.ToLower(s[name:start])] {
+				return start
+			}
+			start = name
+		case s[start-1] == '"' || s[start-1] == '`' || s[start-1] == ']':
+			open := s[start-1]
+			if open == ']' {
+				open = '['
+			}
+			i := strings.LastIndexByte(s[:start-1], open)
+			if i < 0 {
+				return -1
+			}
+			start = i
+		case isNamePart(s[start-1]):
+			for start > 0 && isNamePart(s[start-1]) {
+				start--
+			}
+		}
+		if start == end {
+			return -1
+		}
+		// qualified name, e.g. t.uid
+		if start > 0 && s[start-1] == '.' {
+			start--
+			end = start
+			continue
+		}
+		return start
+	}
+}
@@ -0,0 +1,140 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// wrappedDialect is a dialect customizing another, e.g. to override its max params
+type wrappedDialect struct {
+	Dialect
+}
+
+func rewriteConfig(d Dialect, emptySlice EmptySliceMode) *config {
+	conf := *defaultConfig.Load()
+	conf.dialect = d
+	conf.emptySlice = emptySlice
+	return &conf
+}
+
+func TestRewriteQuery_Literals(t *testing.T) {
+	query, args, err := rewriteQuery(rewriteConfig(Postgres, EmptySliceNull), `select 'what?', "why?" from t -- why?
+where a = ? /* ? */ and b ??| array['x'] and c = $$it's ?$$ and d = $fn$?$fn$ and e in ?`, []any{1, []int{2, 3}})
+	assert.Nil(t, err)
+	assert.Equal(t, `select 'what?', "why?" from t -- why?
+where a = $1 /* ? */ and b ?| array['x'] and c = $$it's ?$$ and d = $fn$?$fn$ and e in ($2,$3)`, query)
+	assert.Equal(t, []any{1, 2, 3}, args)
+
+	// backslash escapes in MySQL strings, kept by dialects wrapping it
+	for _, d := range []Dialect{MySQL, wrappedDialect{MySQL}} {
+		query, args, err = rewriteQuery(rewriteConfig(d, EmptySliceNull), `select * from t where a = 'it\'s?' and b = ?`, []any{1})
+		assert.Nil(t, err)
+		assert.Equal(t, `select * from t where a = 'it\'s?' and b = ?`, query)
+		assert.Equal(t, []any{1}, args)
+	}
+
+	// "#" comments in MySQL, kept by dialects wrapping it
+	for _, d := range []Dialect{MySQL, wrappedDialect{MySQL}} {
+		query, args, err = rewriteQuery(rewriteConfig(d, EmptySliceNull), "select * from t where a = ? # why?\nand b = ?", []any{1, 2})
+		assert.Nil(t, err)
+		assert.Equal(t, "select * from t where a = ? # why?\nand b = ?", query)
+		assert.Equal(t, []any{1, 2}, args)
+	}
+	query, _, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceNull), "select a # ? from t", []any{1})
+	assert.Nil(t, err)
+	assert.Equal(t, "select a # $1 from t", query)
+
+	// "E" ending a keyword doesn't start an escape string
+	query, args, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceNull), `select * from t where name LIKE'a\' and b = ? and c = E'\'?'`, []any{1})
+	assert.Nil(t, err)
+	assert.Equal(t, `select * from t where name LIKE'a\' and b = $1 and c = E'\'?'`, query)
+	assert.Equal(t, []any{1}, args)
+
+	_, _, err = rewriteQuery(rewriteConfig(MySQL, EmptySliceNull), "select * from t where a = ? and b = ?", []any{1})
+	assert.True(t, errors.Is(err, ErrPlaceholderMismatch))
+	_, _, err = rewriteQuery(rewriteConfig(MySQL, EmptySliceNull), "select * from t where a = '?'", []any{1})
+	assert.True(t, errors.Is(err, ErrPlaceholderMismatch))
+}
+
+func Test_GetOne_PlaceholderMismatch(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	assert.Nil(t, InsertOne(ctx, db, "userinfo", &UserInfo{Username: "what?"}))
+
+	u, err := GetOne[UserInfo](ctx, db, "select * from userinfo where username = 'what?' and uid = ?", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), u.Uid)
+
+	u, err = GetOne[UserInfo](ctx, db, "select * from userinfo where uid = :uid", sql.Named("uid", 1))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), u.Uid)
+
+	_, err = GetOne[UserInfo](ctx, db, "select * from userinfo where uid = ?")
+	assert.True(t, errors.Is(err, ErrPlaceholderMismatch))
+}
+
+func TestRewriteQuery_EmptySlice(t *testing.T) {
+	query, args, err := rewriteQuery(rewriteConfig(Postgres, EmptySliceRewrite),
+		`select * from t where t.uid in ? and "dept" NOT IN ? and (a, b) in ? and c = ?`, []any{[]int{}, []string{}, [][]any{}, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, `select * from t where 1=0 and 1=1 and 1=0 and c = $1`, query)
+	assert.Equal(t, []any{1}, args)
+
+	// function calls, but not keywords, are part of the operand
+	query, args, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceRewrite),
+		`select * from t where lower(name) in ? and s.f(a, (b)) not in ? and c = ? AND(a, b) in ?`, []any{[]string{}, []int{}, 1, [][]any{}})
+	assert.Nil(t, err)
+	assert.Equal(t, `select * from t where 1=0 and 1=1 and c = $1 AND 1=0`, query)
+	assert.Equal(t, []any{1}, args)
+
+	// the args of placeholders in the operand can't be removed with it
+	_, _, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceRewrite), "select * from t where coalesce(a, ?) in ?", []any{5, []int{}})
+	assert.True(t, errors.Is(err, ErrEmptyInClause))
+	query, args, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceRewrite), "select * from t where a = ? and coalesce(b, 0) in ?", []any{5, []int{}})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from t where a = $1 and 1=0", query)
+	assert.Equal(t, []any{5}, args)
+
+	_, _, err = rewriteQuery(rewriteConfig(Postgres, EmptySliceRewrite), "select * from t where uid = ?", []any{[]int{}})
+	assert.True(t, errors.Is(err, ErrEmptyInClause))
+	_, _, err = rewriteQuery(rewriteConfig(MySQL, EmptySliceError), "select * from t where uid in ?", []any{[]int{}})
+	assert.True(t, errors.Is(err, ErrEmptyInClause))
+
+	query, _, err = rewriteQuery(rewriteConfig(MySQL, EmptySliceNull), "select * from t where uid not in ?", []any{[]int{}})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from t where uid not in (NULL)", query)
+}
+
+func Test_GetMany_EmptySlice(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	assert.Nil(t, InsertOne(ctx, db, "userinfo", &UserInfo{Username: "a"}))
+
+	var queries int
+	count := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		queries++
+		return next(ctx)
+	})
+
+	users, err := GetMany[UserInfo](ctx, db, "select * from userinfo where uid in ?", []int64{}, WithEmptySliceMode(EmptySliceSkip), count)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(users))
+	assert.NotNil(t, users)
+	assert.Equal(t, 0, queries)
+
+	users, err = GetMany[UserInfo](ctx, db, "select * from userinfo where uid not in ?", []int64{}, WithEmptySliceMode(EmptySliceRewrite))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+
+	users, err = GetMany[UserInfo](ctx, db, "select * from userinfo where lower(username) not in ? and uid = ?", []string{}, 1, WithEmptySliceMode(EmptySliceRewrite))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+
+	SetEmptySliceMode(EmptySliceError)
+	defer SetEmptySliceMode(EmptySliceNull)
+	_, err = GetOne[UserInfo](ctx, db, "select * from userinfo where uid in ?", []int64{})
+	assert.True(t, errors.Is(err, ErrEmptyInClause))
+}
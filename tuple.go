@@ -0,0 +1,172 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TuplesArg is a list of tuples, see [Tuples]
+type TuplesArg struct {
+	rows [][]any
+}
+
+// Tuples returns an arg expanded to a list of row values, for IN predicates on several columns
+//
+//	users, err := orm.GetMany[UserRole](ctx, db, "select * from user_role where (uid, role) in ?",
+//		orm.Tuples([]any{1, "admin"}, []any{2, "dev"}))
+//
+// the query becomes "... where (uid, role) in ((?,?),(?,?))". [][]any and slices of structs are expanded
+// the same way, the tagged fields of a struct being the values of its tuple in declaration order.
+// when row values are disabled, the predicate is rewritten to
+// "((uid = ? AND role = ?) OR (uid = ? AND role = ?))". see [SetRowValues]
+func Tuples(rows ...[]any) TuplesArg {
+	return TuplesArg{rows: rows}
+}
+
+// tupleRows returns the tuples of arg, ok is false if arg isn't a list of tuples
+func tupleRows(conf *config, arg any) (rows [][]any, ok bool) {
+	if tuples, ok := arg.(TuplesArg); ok {
+		return tuples.rows, true
+	}
+	if !isSliceArg(arg) {
+		return nil, false
+	}
+
+	sv := reflect.ValueOf(arg)
+	elem := sv.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	switch {
+	case elem.Implements(valuerType) || reflect.PointerTo(elem).Implements(valuerType):
+		return nil, false
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8:
+		rows = make([][]any, sv.Len())
+		for i := range rows {
+			row := reflect.Indirect(sv.Index(i))
+			rows[i] = make([]any, row.Len())
+			for j := range rows[i] {
+				rows[i][j] = row.Index(j).Interface()
+			}
+		}
+		return rows, true
+	case elem.Kind() == reflect.Struct && elem != timeType:
+		rows = make([][]any, sv.Len())
+		for i := range rows {
+			values := tagParser.Parse(conf.tagName, sv.Index(i).Interface())
+			rows[i] = make([]any, 0, values.Len())
+			for _, value := range values.Iter() {
+				rows[i] = append(rows[i], columnArg(value))
+			}
+		}
+		return rows, true
+	}
+	return nil, false
+}
+
+var valuerType = reflect.TypeFor[driver.Valuer]()
+
+// writeTuples write the placeholders of rows, or rewrite the IN predicate w ends with
+// when row values are disabled
+func writeTuples(w *sqlWriter, conf *config, args []any, rows [][]any) ([]any, error) {
+	for i, row := range rows {
+		if len(row) == 0 || len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("tuple %d has %d values, expected %d", i, len(row), len(rows[0]))
+		}
+	}
+	if !conf.rowValues {
+		return writeTuplesPredicate(w, args, rows)
+	}
+
+	w.WriteString("(")
+	for i, row := range rows {
+		if i > 0 {
+			w.WriteString(separator)
+		}
+		w.WriteString("(")
+		for j, value := range row {
+			if j > 0 {
+				w.WriteString(separator)
+			}
+			w.writePlaceholder()
+			args = append(args, value)
+		}
+		w.WriteString(")")
+	}
+	w.WriteString(")")
+	return args, nil
+}
+
+// writeTuplesPredicate rewrite "(a, b) IN" at the end of w to "((a = ? AND b = ?) OR (a = ? AND b = ?))",
+// "(a, b) NOT IN" to "NOT (...)"
+func writeTuplesPredicate(w *sqlWriter, args []any, rows [][]any) ([]any, error) {
+	s := w.String()
+	start, operand, not, err := inPredicate(s)
+	if err != nil {
+		return nil, err
+	}
+	if start < w.placeholderEnd {
+		// the operand is repeated for every tuple, but not the args of its placeholders
+		return nil, fmt.Errorf("the operand of IN has placeholders")
+	}
+	columns := splitTuple(operand)
+	if len(columns) != len(rows[0]) {
+		return nil, fmt.Errorf("%d columns for tuples of %d values", len(columns), len(rows[0]))
+	}
+
+	w.Reset()
+	w.WriteString(s[:start])
+	if start > 0 && isNamePart(s[start-1]) {
+		w.WriteByte(' ')
+	}
+	if not {
+		w.WriteString("NOT ")
+	}
+	w.WriteString("(")
+	for i, row := range rows {
+		if i > 0 {
+			w.WriteString(" OR ")
+		}
+		w.WriteString("(")
+		for j, value := range row {
+			if j > 0 {
+				w.WriteString(" AND ")
+			}
+			w.WriteString(columns[j])
+			w.WriteString(" = ")
+			w.writePlaceholder()
+			args = append(args, value)
+		}
+		w.WriteString(")")
+	}
+	w.WriteString(")")
+	return args, nil
+}
+
+// splitTuple returns the expressions of a row value, e.g. "a" and "b" for "(a, b)"
+func splitTuple(operand string) []string {
+	operand = strings.TrimSpace(operand)
+	if !strings.HasPrefix(operand, "(") || !strings.HasSuffix(operand, ")") {
+		return []string{operand}
+	}
+	operand = operand[1 : len(operand)-1]
+
+	var columns []string
+	depth, start := 0, 0
+	for i := 0; i < len(operand); i++ {
+		switch operand[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				columns = append(columns, strings.TrimSpace(operand[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(columns, strings.TrimSpace(operand[start:]))
+}
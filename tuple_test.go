@@ -0,0 +1,79 @@
+package orm
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type userRoleKey struct {
+	TenantId int64 `orm:"tenant_id"`
+	Uid      int64 `orm:"uid"`
+}
+
+func TestRewriteQuery_Tuples(t *testing.T) {
+	query, args, err := rewriteQuery(rewriteConfig(Postgres, EmptySliceNull), "select * from t where (a, b) in ? and c = ?",
+		[]any{Tuples([]any{1, "x"}, []any{2, "y"}), 3})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from t where (a, b) in (($1,$2),($3,$4)) and c = $5", query)
+	assert.Equal(t, []any{1, "x", 2, "y", 3}, args)
+
+	conf := rewriteConfig(Postgres, EmptySliceNull)
+	WithRowValues(false)(conf)
+	query, args, err = rewriteQuery(conf, "select * from t where (t.a, f(b, c)) not in ? and c = ?",
+		[]any{[][]any{{1, "x"}, {2, "y"}}, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from t where NOT ((t.a = $1 AND f(b, c) = $2) OR (t.a = $3 AND f(b, c) = $4)) and c = $5", query)
+	assert.Equal(t, []any{1, "x", 2, "y", 3}, args)
+
+	_, _, err = rewriteQuery(rewriteConfig(MySQL, EmptySliceNull), "select * from t where (a, b) in ?", []any{Tuples([]any{1, 2}, []any{3})})
+	assert.NotNil(t, err)
+
+	// []byte is a single value
+	query, args, err = rewriteQuery(rewriteConfig(MySQL, EmptySliceNull), "select * from t where a = ?", []any{[]byte("ab")})
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from t where a = ?", query)
+	assert.Equal(t, []any{[]byte("ab")}, args)
+}
+
+func Test_GetMany_Tuples(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	_, err := db.Exec(`CREATE TABLE user_role (tenant_id INTEGER, uid INTEGER, role VARCHAR(64), PRIMARY KEY (tenant_id, uid))`)
+	assert.Nil(t, err)
+	err = InsertMany(ctx, db, "user_role", []UserRole{{1, 1, "admin"}, {2, 1, "guest"}, {2, 2, "dev"}})
+	assert.Nil(t, err)
+
+	keys := []userRoleKey{{1, 1}, {2, 2}, {3, 3}}
+	for _, rowValues := range []bool{true, false} {
+		roles, err := Pluck[string](ctx, db, "select role from user_role where (tenant_id, uid) in ? order by role", keys, WithRowValues(rowValues))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"admin", "dev"}, roles)
+
+		roles, err = Pluck[string](ctx, db, "select role from user_role where (tenant_id, uid) not in ?", Tuples([]any{1, 1}, []any{2, 2}), WithRowValues(rowValues))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"guest"}, roles)
+	}
+}
+
+func Test_DeleteMany_RowValues(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+	_, err := db.Exec(`CREATE TABLE user_role (tenant_id INTEGER, uid INTEGER, role VARCHAR(64), PRIMARY KEY (tenant_id, uid))`)
+	assert.Nil(t, err)
+
+	var queries []string
+	record := WithInterceptors(func(ctx context.Context, info *QueryInfo, next func(context.Context) error) error {
+		queries = append(queries, info.Query)
+		return next(ctx)
+	})
+	roles := []UserRole{{1, 1, "admin"}, {2, 1, "guest"}, {2, 2, "dev"}}
+	assert.Nil(t, InsertMany(ctx, db, "user_role", roles))
+	err = DeleteMany(ctx, db, "user_role", roles[:2], WithDialect(SQLite), WithRowValues(false), record)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`DELETE FROM user_role WHERE ("tenant_id"=? AND "uid"=?) OR ("tenant_id"=? AND "uid"=?)`}, queries)
+
+	left, err := Pluck[string](ctx, db, "select role from user_role")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dev"}, left)
+}
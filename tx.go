@@ -0,0 +1,134 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	ErrTxNotSupported = fmt.Errorf("transaction not supported")
+)
+
+var (
+	_ TxBeginner = (*sql.DB)(nil)
+)
+
+// TxBeginner is a DB able to begin transactions, e.g. *sql.DB
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+var savepointSeq atomic.Int64
+
+// WithTx run fn in a transaction. the transaction is committed when fn returns nil,
+// and rolled back when fn returns an error or panics.
+//
+// db is usually a *sql.DB. when db is a *sql.Tx, e.g. the tx passed to an outer fn,
+// fn runs in a savepoint of it instead, so WithTx calls can be nested.
+// when db is a [*Client], tx is a client of the same config.
+//
+// with [WithTxRetries], the whole transaction is retried when it fails because of
+// [ErrConcurrencyUpdate], or a serialization failure or deadlock reported by the database.
+// retries only apply to the outermost transaction, fn must be safe to run again
+func WithTx(ctx context.Context, db DB, fn func(tx DB) error, opts ...func(*config)) error {
+	if c, ok := db.(*Client); ok {
+		// statements in the transaction run with the config of the client too
+		inner := fn
+		fn = func(tx DB) error {
+			return inner(&Client{db: tx, conf: c.conf})
+		}
+	}
+
+	conf, db := configOf(db)
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	if tx, ok := db.(*sql.Tx); ok {
+		return runSavepoint(ctx, &conf, tx, fn)
+	}
+
+	beginner, ok := db.(TxBeginner)
+	if !ok {
+		return ErrTxNotSupported
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := runTx(ctx, &conf, beginner, fn)
+		if err == nil || attempt >= conf.txRetries || !isRetryableTxError(&conf, err) || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// inTx run fn in a transaction, or in a savepoint when db is a *sql.Tx. it is not retried
+func inTx(ctx context.Context, conf *config, db DB, fn func(tx DB) error) error {
+	if tx, ok := db.(*sql.Tx); ok {
+		return runSavepoint(ctx, conf, tx, fn)
+	}
+	beginner, ok := db.(TxBeginner)
+	if !ok {
+		return ErrTxNotSupported
+	}
+	return runTx(ctx, conf, beginner, fn)
+}
+
+func runTx(ctx context.Context, conf *config, beginner TxBeginner, fn func(tx DB) error) error {
+	tx, err := beginner.BeginTx(ctx, conf.txOptions)
+	if err != nil {
+		return err
+	}
+	if db, ok := beginner.(*sql.DB); ok {
+		txDBs.Store(tx, db)
+		defer txDBs.Delete(tx)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func runSavepoint(ctx context.Context, conf *config, tx *sql.Tx, fn func(tx DB) error) error {
+	name := "orm_sp_" + strconv.FormatInt(savepointSeq.Add(1), 10)
+	if _, err := execContext(ctx, conf, tx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = execContext(ctx, conf, tx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_, _ = execContext(ctx, conf, tx, "ROLLBACK TO SAVEPOINT "+name)
+		return err
+	}
+	_, err := execContext(ctx, conf, tx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+func isRetryableTxError(conf *config, err error) bool {
+	return errors.Is(err, ErrConcurrencyUpdate) || conf.dialect.IsSerializationFailure(err)
+}
+
+// sqlState returns the SQLSTATE code of err if the driver exposes it, e.g. lib/pq and pgx
+func sqlState(err error) string {
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) {
+		return e.SQLState()
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func countUsers(t *testing.T, db DB) int {
+	users, err := GetMany[UserInfo](context.Background(), db, "select * from userinfo")
+	assert.Nil(t, err)
+	return len(users)
+}
+
+func Test_WithTx(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	err := WithTx(ctx, db, func(tx DB) error {
+		return InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "commit"})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, countUsers(t, db))
+
+	errRollback := errors.New("rollback")
+	err = WithTx(ctx, db, func(tx DB) error {
+		assert.Nil(t, InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "rollback"}))
+		return errRollback
+	})
+	assert.True(t, errors.Is(err, errRollback))
+	assert.Equal(t, 1, countUsers(t, db))
+
+	assert.Panics(t, func() {
+		_ = WithTx(ctx, db, func(tx DB) error {
+			assert.Nil(t, InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "panic"}))
+			panic("panic")
+		})
+	})
+	assert.Equal(t, 1, countUsers(t, db))
+}
+
+func Test_WithTx_Savepoint(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	err := WithTx(ctx, db, func(tx DB) error {
+		assert.Nil(t, InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "outer"}))
+		err := WithTx(ctx, tx, func(tx DB) error {
+			assert.Nil(t, InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "inner"}))
+			return errors.New("rollback inner")
+		})
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, countUsers(t, tx))
+
+		return WithTx(ctx, tx, func(tx DB) error {
+			return InsertOne(ctx, tx, "userinfo", &UserInfo{Username: "inner2"})
+		})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, countUsers(t, db))
+}
+
+func Test_WithTx_Retry(t *testing.T) {
+	db := initDb(t)
+	ctx := context.Background()
+
+	attempts := 0
+	err := WithTx(ctx, db, func(tx DB) error {
+		attempts++
+		if attempts < 3 {
+			return ErrConcurrencyUpdate
+		}
+		return nil
+	}, WithTxRetries(2))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = WithTx(ctx, db, func(tx DB) error {
+		attempts++
+		return ErrConcurrencyUpdate
+	}, WithTxRetries(1))
+	assert.True(t, errors.Is(err, ErrConcurrencyUpdate))
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	err = WithTx(ctx, db, func(tx DB) error {
+		attempts++
+		return errors.New("not retryable")
+	}, WithTxRetries(3))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+}